@@ -0,0 +1,18 @@
+//go:build !linux && !darwin
+
+package ecs
+
+import "unsafe"
+
+// allocNoScan returns size Bytes of zeroed memory that the GC don't scan.
+// In this platform the memory is allocated in the Go heap as a pointer-free buffer.
+func allocNoScan(size uintptr) []byte {
+	if size == 0 {
+		return nil
+	}
+	words := make([]uint64, (size+7)/8)
+	return unsafe.Slice((*byte)(unsafe.Pointer(&words[0])), size)
+}
+
+// freeNoScan releases the memory allocated by allocNoScan
+func freeNoScan([]byte) {}
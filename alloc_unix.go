@@ -0,0 +1,28 @@
+//go:build linux || darwin
+
+package ecs
+
+import "syscall"
+
+// allocNoScan returns size Bytes of zeroed memory allocated outside the Go heap.
+// The GC don't scan or count this memory, so it must only hold pointer-free data
+// and be released with freeNoScan.
+func allocNoScan(size uintptr) []byte {
+	if size == 0 {
+		return nil
+	}
+	data, err := syscall.Mmap(-1, 0, int(size), syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_ANON|syscall.MAP_PRIVATE)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+// freeNoScan releases the memory allocated by allocNoScan
+func freeNoScan(data []byte) {
+	if len(data) > 0 {
+		if err := syscall.Munmap(data); err != nil {
+			panic(err)
+		}
+	}
+}
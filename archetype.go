@@ -1,6 +1,7 @@
 package ecs
 
 import (
+	"math"
 	"unsafe"
 )
 
@@ -19,7 +20,30 @@ AddComponent adds the ComponentID to the entity, moving it to another archetype.
 
 RemComponent removes the ComponentID from the entity, moving it to another archetype.
 
-Query returns a QueryCursor for the mask
+AddComponents adds the components to the entity, moving it directly to the final archetype.
+
+RemComponents removes the components from the entity, moving it directly to the final archetype.
+
+SetComponents changes the components of the entity to the ones in the mask, with a single move.
+In the unconstrained graph, the components beyond MaskTotalBits are kept.
+
+# Query returns a QueryCursor for the mask
+
+# QueryDynamic returns a QueryCursor for the DynamicMask, that can contain components beyond MaskTotalBits
+
+# Component returns the component pointer for the entity, or nil if the entity don't have it
+
+# SetComponent copies the value to the component of the entity, returning false if it fails
+
+# Compact shrinks the columns and entity lists of the underused archetypes, following the CompactionPolicy
+
+# SetCompactionPolicy changes the policy used by Compact and by the automatic compaction
+
+# RemEmptyArchetypes removes all the archetypes without entities, except the root archetype
+
+# Maintain is called once per frame to remove the archetypes empty for CompactionPolicy.ReclaimAfter calls
+
+Stats collects the entity count and memory usage of the archetypes and components
 */
 type ArchetypeGraph interface {
 	Add(EntityID, ...ComponentID)
@@ -27,7 +51,18 @@ type ArchetypeGraph interface {
 	Get(EntityID) (*Archetype, uint32)
 	AddComponent(EntityID, ComponentID)
 	RemComponent(EntityID, ComponentID)
+	AddComponents(EntityID, ...ComponentID)
+	RemComponents(EntityID, ...ComponentID)
+	SetComponents(EntityID, Mask)
 	Query(Mask) QueryCursor
+	QueryDynamic(DynamicMask) QueryCursor
+	Component(EntityID, ComponentID) unsafe.Pointer
+	SetComponent(EntityID, ComponentID, interface{}) bool
+	Compact()
+	SetCompactionPolicy(CompactionPolicy)
+	RemEmptyArchetypes()
+	Maintain()
+	Stats() ArchetypeGraphStats
 }
 
 // ArchEdge defines the link between archetypes.
@@ -41,16 +76,79 @@ type ArchEdge struct {
 // it contains the component ids and the storage for the components.
 // when a component is a singleton, the Storage is nil and the data is accessed
 // by the ComponentFactory.SingletonPtr
+// The columns are dense, with the components with data first, in the same order of the storage list,
+// followed by the tags, that don't have data to move. index maps the ComponentID to its column.
+// edges and entities are allocated when the archetype is connected or receives entities,
+// so archetypes created by rare component combinations are cheap.
+// The mask keeps only the components lower than MaskTotalBits, the unconstrained graph
+// keeps the full set of components in dynMask.
+// stamp is the graph layout when this copy of the archetype was made, used by the ecsdebug
+// build to detect pointers to archetypes that were moved.
+// moves is incremented by the ecsdebug build every time the rows of the archetype are moved or removed,
+// it's shared by all the copies of the archetype so the queries can detect changes in the archetypes they match.
 type Archetype struct {
 	mask     Mask
-	columns  [MaxComponentCount]Storage
+	dynMask  DynamicMask
+	storage  []ComponentID
+	columns  []Storage
+	index    []uint16 // column+1 for every ComponentID lower than len(index), 0 if the archetype don't have it
 	edges    map[ComponentID]ArchEdge
 	entities []EntityID
+	stamp    uint64
+	layout   *uint64
+	moves    *uint64
+	idle     uint // Maintain calls since the archetype became empty
 }
 
 // Component returns the pointer to the component data at col and row in this archetype
 func (a *Archetype) Component(col ComponentID, row uint32) unsafe.Pointer {
-	return a.columns[col].Get(uint(row))
+	if debugChecks {
+		a.checkStale()
+		if row >= uint32(len(a.entities)) {
+			panic("ecs: invalid row for archetype (was the entity moved or removed?)")
+		}
+	}
+	return a.column(col).Get(uint(row))
+}
+
+// ArchetypeMemoryStats is the memory used by the columns of an archetype
+type ArchetypeMemoryStats struct {
+	Bytes     uint // memory reserved by the columns, in Bytes
+	ScanBytes uint // memory that the GC has to scan for pointers, in Bytes
+}
+
+// MemoryStats returns the memory used by the columns of this archetype
+func (a *Archetype) MemoryStats() ArchetypeMemoryStats {
+	var stats ArchetypeMemoryStats
+	for i := range a.storage {
+		columnStats := a.columns[i].Stats()
+		stats.Bytes += columnStats.ItemSize * columnStats.Cap
+		stats.ScanBytes += columnStats.ScanBytes
+	}
+	return stats
+}
+
+// checkStale panics if the archetype was moved in memory after the pointer for it was taken
+func (a *Archetype) checkStale() {
+	if a.layout != nil && a.stamp != *a.layout {
+		panic("ecs: stale *Archetype used after the ArchetypeGraph grew (call ArchetypeGraph.Get again)")
+	}
+}
+
+// column returns the Storage for the component or nil if the archetype don't have it
+func (a *Archetype) column(col ComponentID) Storage {
+	if col >= uint(len(a.index)) || a.index[col] == 0 {
+		return nil
+	}
+	return a.columns[a.index[col]-1]
+}
+
+// setEdge connects the archetype to another by the component, allocating the edges on first use
+func (a *Archetype) setEdge(col ComponentID, edge ArchEdge) {
+	if a.edges == nil {
+		a.edges = make(map[ComponentID]ArchEdge)
+	}
+	a.edges[col] = edge
 }
 
 // archetypeEntityIndex informs in wich archetype and row the components for the entity is stored.
@@ -60,10 +158,16 @@ type archetypeEntityIndex struct {
 }
 
 type archetypeGraph struct {
-	factory      ComponentFactory
-	entityMap    map[EntityID]archetypeEntityIndex
-	archetypeMap map[Mask]int
-	archetypes   []Archetype
+	factory         ComponentFactory
+	entityIndex     entityIndex
+	archetypeMap    map[Mask]int
+	archetypes      []Archetype
+	unconstrained   bool
+	dynArchetypeMap map[string]int
+	version         uint64 // incremented on every change in the archetype rows
+	layout          uint64 // incremented every time the archetypes are moved in memory
+	policy          CompactionPolicy
+	sparse          []*sparseSet // indexed by ComponentID, nil for components stored in the archetypes
 }
 
 // NewarchetypeGraph returns an ArchetypeGraph responsible for creating and caching the
@@ -71,36 +175,64 @@ type archetypeGraph struct {
 func NewArchetypeGraph(factory ComponentFactory) ArchetypeGraph {
 	arch := &archetypeGraph{
 		factory,
-		make(map[EntityID]archetypeEntityIndex),
+		entityIndex{},
+		make(map[Mask]int),
+		make([]Archetype, 0, 256),
+		false,
+		nil,
+		0,
+		0,
+		DefaultCompactionPolicy,
+		nil,
+	}
+	arch.archetypeMap[Mask{}] = arch.newArchetype(Mask{}, nil, 0)
+	return arch
+}
+
+// NewUnconstrainedArchetypeGraph returns an ArchetypeGraph without the MaxComponentCount limit.
+// It's slower than the version returned by NewArchetypeGraph, as the archetypes are found by
+// DynamicMask, and should be used with the factory returned by NewUnconstrainedComponentFactory.
+func NewUnconstrainedArchetypeGraph(factory ComponentFactory) ArchetypeGraph {
+	arch := &archetypeGraph{
+		factory,
+		entityIndex{},
 		make(map[Mask]int),
 		make([]Archetype, 0, 256),
+		true,
+		make(map[string]int),
+		0,
+		0,
+		DefaultCompactionPolicy,
+		nil,
 	}
-	arch.archetypeMap[Mask{}] = arch.newArchetype(Mask{})
+	arch.dynArchetypeMap[DynamicMask{}.Key()] = arch.newArchetype(Mask{}, DynamicMask{}, 0)
 	return arch
 }
 
 func (a *archetypeGraph) Add(entity EntityID, components ...ComponentID) {
-	_, exists := a.entityMap[entity]
+	_, exists := a.entityIndex.get(entity)
 	if exists {
 		panic("trying to add the same entity twice (did you mean AddComponent instead?)")
 	}
 
+	components = a.addSparse(entity, components)
 	archetype := a.findOrCreateArchetype(components)
 	row := a.getUnusedRow(archetype, entity)
 
-	a.entityMap[entity] = archetypeEntityIndex{archetype, row}
+	a.entityIndex.set(entity, archetypeEntityIndex{archetype, row})
 }
 
 func (a *archetypeGraph) Rem(entity EntityID) {
-	cache, ok := a.entityMap[entity]
+	cache, ok := a.entityIndex.get(entity)
 	if ok {
 		a.compressRow(cache.archetype, cache.row)
-		delete(a.entityMap, entity)
+		a.entityIndex.rem(entity)
+		a.remSparse(entity)
 	}
 }
 
 func (a *archetypeGraph) Get(entity EntityID) (*Archetype, uint32) {
-	cache, ok := a.entityMap[entity]
+	cache, ok := a.entityIndex.get(entity)
 	if !ok {
 		return nil, 0
 	}
@@ -109,14 +241,19 @@ func (a *archetypeGraph) Get(entity EntityID) (*Archetype, uint32) {
 }
 
 func (a *archetypeGraph) AddComponent(entity EntityID, component ComponentID) {
-	cache, ok := a.entityMap[entity]
+	cache, ok := a.entityIndex.get(entity)
 	if !ok {
 		// should panic?
 		return
 	}
 
+	if set := a.sparseSet(component); set != nil {
+		set.add(entity)
+		return
+	}
+
 	// If already have the component, do nothing
-	if a.archetypes[cache.archetype].mask.IsSet(uint64(component)) {
+	if a.archetypes[cache.archetype].column(component) != nil {
 		return
 	}
 
@@ -124,76 +261,259 @@ func (a *archetypeGraph) AddComponent(entity EntityID, component ComponentID) {
 }
 
 func (a *archetypeGraph) RemComponent(entity EntityID, component ComponentID) {
-	cache, ok := a.entityMap[entity]
+	cache, ok := a.entityIndex.get(entity)
 	if !ok {
 		return
 	}
 
-	if !a.archetypes[cache.archetype].mask.IsSet(uint64(component)) {
+	if set := a.sparseSet(component); set != nil {
+		set.rem(entity)
+		return
+	}
+
+	if a.archetypes[cache.archetype].column(component) == nil {
 		return
 	}
 	// keep the entity even if it has no components, because it still exists in the graph
 	a.updateEntityRelation(entity, component, cache.archetype, cache.row, false)
 }
 
+func (a *archetypeGraph) AddComponents(entity EntityID, components ...ComponentID) {
+	a.updateComponents(entity, components, true)
+}
+
+func (a *archetypeGraph) RemComponents(entity EntityID, components ...ComponentID) {
+	a.updateComponents(entity, components, false)
+}
+
+func (a *archetypeGraph) SetComponents(entity EntityID, mask Mask) {
+	cache, ok := a.entityIndex.get(entity)
+	if !ok {
+		return
+	}
+
+	for id, set := range a.sparse {
+		if set != nil && uint(id) < MaskTotalBits && !mask.IsSet(uint64(id)) {
+			set.rem(entity)
+		}
+	}
+	// the sparse sets of components never used are created here, so they don't become columns
+	for bit := mask.NextBitSet(0); bit < MaskTotalBits; bit = mask.NextBitSet(bit + 1) {
+		if set := a.sparseSet(ComponentID(bit)); set != nil {
+			set.add(entity)
+			mask.Clear(uint64(bit))
+		}
+	}
+
+	if a.unconstrained {
+		// keep the components beyond MaskTotalBits
+		dynMask := a.archetypes[cache.archetype].dynMask.Clone()
+		for i := 0; i < len(mask) && i < len(dynMask); i++ {
+			dynMask[i] = 0
+		}
+		for bit := mask.NextBitSet(0); bit < MaskTotalBits; bit = mask.NextBitSet(bit + 1) {
+			dynMask.Set(uint64(bit))
+		}
+		a.moveToArchetype(entity, cache, a.findOrCreateDynamicArchetype(dynMask))
+		return
+	}
+	a.moveToArchetype(entity, cache, a.findOrCreateMaskArchetype(mask))
+}
+
+// updateComponents adds or removes the components, moving the entity directly to the final archetype
+func (a *archetypeGraph) updateComponents(entity EntityID, components []ComponentID, toAdd bool) {
+	cache, ok := a.entityIndex.get(entity)
+	if !ok {
+		return
+	}
+
+	arch := &a.archetypes[cache.archetype]
+	mask := arch.mask
+	var dynMask DynamicMask
+	if a.unconstrained {
+		dynMask = arch.dynMask.Clone()
+	}
+
+	for _, id := range components {
+		if set := a.sparseSet(id); set != nil {
+			if toAdd {
+				set.add(entity)
+			} else {
+				set.rem(entity)
+			}
+			continue
+		}
+
+		switch {
+		case a.unconstrained && toAdd:
+			dynMask.Set(uint64(id))
+		case a.unconstrained:
+			dynMask.Clear(uint64(id))
+		case toAdd:
+			mask.Set(uint64(id))
+		default:
+			mask.Clear(uint64(id))
+		}
+	}
+
+	if a.unconstrained {
+		a.moveToArchetype(entity, cache, a.findOrCreateDynamicArchetype(dynMask))
+		return
+	}
+	a.moveToArchetype(entity, cache, a.findOrCreateMaskArchetype(mask))
+}
+
+// moveToArchetype moves the entity to the archetype, copying the components in common with a single move
+func (a *archetypeGraph) moveToArchetype(entity EntityID, cache archetypeEntityIndex, to int) {
+	if to == cache.archetype {
+		return
+	}
+	row := a.moveEntity(entity, cache.archetype, to, cache.row)
+	a.entityIndex.set(entity, archetypeEntityIndex{to, row})
+}
+
 func (a *archetypeGraph) Query(mask Mask) QueryCursor {
+	if len(a.sparse) > 0 {
+		return a.QueryDynamic(mask.Dynamic())
+	}
+
+	var qc QueryCursor
+	qc.prepare(mask, nil, nil, a)
+	return qc
+}
+
+func (a *archetypeGraph) QueryDynamic(mask DynamicMask) QueryCursor {
+	mask, filters := a.sparseFilters(mask)
+
+	var dynMask DynamicMask
+	if mask.NextBitSet(MaskTotalBits) < mask.TotalBits() {
+		dynMask = mask
+	}
+
 	var qc QueryCursor
-	qc.prepare(mask, a)
+	qc.prepare(mask.Mask(), dynMask, filters, a)
 	return qc
 }
 
+func (a *archetypeGraph) Component(entity EntityID, component ComponentID) unsafe.Pointer {
+	column, row := a.storageFor(entity, component)
+	if column == nil {
+		return nil
+	}
+	return column.Get(row)
+}
+
+func (a *archetypeGraph) SetComponent(entity EntityID, component ComponentID, value interface{}) bool {
+	column, row := a.storageFor(entity, component)
+	if column == nil {
+		return false
+	}
+	return column.Set(row, value)
+}
+
+// storageFor returns the Storage and row of the entity component, from the archetype or the sparse set.
+// Returns a nil Storage if the entity don't have the component
+func (a *archetypeGraph) storageFor(entity EntityID, component ComponentID) (Storage, uint) {
+	// sets not created yet don't have entities, and reads must not change the graph
+	if component < uint(len(a.sparse)) && a.sparse[component] != nil {
+		set := a.sparse[component]
+		row := set.row(entity)
+		if row == 0 {
+			return nil, 0
+		}
+		return set.storage, uint(row - 1)
+	}
+
+	cache, ok := a.entityIndex.get(entity)
+	if !ok {
+		return nil, 0
+	}
+	column := a.archetypes[cache.archetype].column(component)
+	if column == nil {
+		return nil, 0
+	}
+	return column, uint(cache.row)
+}
+
 func (a *archetypeGraph) findOrCreateArchetype(components []ComponentID) int {
 	if len(components) == 0 {
 		return 0
 	}
 
-	mask := MakeComponentMask(components...)
+	if a.unconstrained {
+		return a.findOrCreateDynamicArchetype(MakeDynamicComponentMask(components...))
+	}
 
+	return a.findOrCreateMaskArchetype(MakeComponentMask(components...))
+}
+
+func (a *archetypeGraph) findOrCreateMaskArchetype(mask Mask) int {
 	arch, ok := a.archetypeMap[mask]
 	if !ok {
-		arch = a.prepareNewArchetype(mask)
+		arch = a.prepareNewArchetype(mask, nil)
 		a.archetypeMap[mask] = arch
 	}
 	return arch
 }
 
+func (a *archetypeGraph) findOrCreateDynamicArchetype(mask DynamicMask) int {
+	key := mask.Key()
+	arch, ok := a.dynArchetypeMap[key]
+	if !ok {
+		arch = a.prepareNewArchetype(mask.Mask(), mask)
+		a.dynArchetypeMap[key] = arch
+	}
+	return arch
+}
+
 func (a *archetypeGraph) updateEntityRelation(
 	entity EntityID, component ComponentID,
 	from int, row uint32, toAdd bool) {
 
 	arch := a.findOrCreateConnection(from, component, toAdd)
 	newRow := a.moveEntity(entity, from, arch, row)
-	a.entityMap[entity] = archetypeEntityIndex{arch, newRow}
+	a.entityIndex.set(entity, archetypeEntityIndex{arch, newRow})
 }
 
 func (a *archetypeGraph) findOrCreateConnection(from int, component ComponentID, toAdd bool) int {
-	fromArch := a.archetypes[from]
+	fromArch := &a.archetypes[from]
 	edge, ok := fromArch.edges[component]
-	if ok && edge.add > -1 {
+	if ok && toAdd && edge.add > -1 {
 		return edge.add
 	}
-
-	mask := fromArch.mask
-	if toAdd {
-		mask.Set(uint64(component))
-	} else {
-		mask.Clear(uint64(component))
+	if ok && !toAdd && edge.rem > -1 {
+		return edge.rem
 	}
 
-	index, ok := a.archetypeMap[mask]
-	if !ok {
-		index = a.prepareNewArchetype(mask)
-		a.archetypeMap[mask] = index
+	var index int
+	if a.unconstrained {
+		mask := fromArch.dynMask.Clone()
+		if toAdd {
+			mask.Set(uint64(component))
+		} else {
+			mask.Clear(uint64(component))
+		}
+		index = a.findOrCreateDynamicArchetype(mask)
+	} else {
+		mask := fromArch.mask
+		if toAdd {
+			mask.Set(uint64(component))
+		} else {
+			mask.Clear(uint64(component))
+		}
+		index = a.findOrCreateMaskArchetype(mask)
 	}
 
-	arch := a.archetypes[index]
+	// the archetypes may have moved when the new one was created
+	fromArch = &a.archetypes[from]
+	arch := &a.archetypes[index]
 
 	if toAdd {
-		arch.edges[component] = ArchEdge{add: -1, rem: from}
-		fromArch.edges[component] = ArchEdge{add: index, rem: -1}
+		arch.setEdge(component, ArchEdge{add: -1, rem: from})
+		fromArch.setEdge(component, ArchEdge{add: index, rem: -1})
 	} else {
-		arch.edges[component] = ArchEdge{add: from, rem: -1}
-		fromArch.edges[component] = ArchEdge{add: -1, rem: index}
+		arch.setEdge(component, ArchEdge{add: from, rem: -1})
+		fromArch.setEdge(component, ArchEdge{add: -1, rem: index})
 	}
 
 	return index
@@ -205,12 +525,10 @@ func (a *archetypeGraph) moveEntity(entity EntityID, from, to int, row uint32) u
 	fromArch := &a.archetypes[from]
 	toArch := &a.archetypes[to]
 
-	mask := fromArch.mask.And(toArch.mask)
-
-	bit := mask.NextBitSet(0)
-	for bit < MaskTotalBits {
-		toArch.columns[bit].Copy(uint(toRow), fromArch.columns[bit].Get(uint(row)))
-		bit = mask.NextBitSet(bit + 1)
+	for i, id := range fromArch.storage {
+		if col := toArch.column(id); col != nil {
+			col.CopyRange(uint(toRow), fromArch.columns[i], uint(row), 1)
+		}
 	}
 
 	a.compressRow(from, row)
@@ -218,68 +536,113 @@ func (a *archetypeGraph) moveEntity(entity EntityID, from, to int, row uint32) u
 	return toRow
 }
 
-func (a *archetypeGraph) newArchetype(mask Mask) int {
+// newArchetype appends an archetype with space for columnCount columns
+func (a *archetypeGraph) newArchetype(mask Mask, dynMask DynamicMask, columnCount int) int {
+	moved := len(a.archetypes) == cap(a.archetypes)
+	if moved {
+		a.layout++
+	}
+
+	var moves *uint64
+	if debugChecks {
+		moves = new(uint64)
+	}
+
 	index := len(a.archetypes)
 	a.archetypes = append(a.archetypes, Archetype{
-		mask:     mask,
-		columns:  [MaxComponentCount]Storage{},
-		edges:    make(map[ComponentID]ArchEdge, MaxComponentCount),
-		entities: make([]EntityID, 0, 1024),
+		mask:    mask,
+		dynMask: dynMask,
+		columns: make([]Storage, 0, columnCount),
+		stamp:   a.layout,
+		layout:  &a.layout,
+		moves:   moves,
 	})
 
+	if moved {
+		for i := range a.archetypes {
+			a.archetypes[i].stamp = a.layout
+		}
+	}
+
 	return index
 }
 
-func (a *archetypeGraph) prepareNewArchetype(mask Mask) int {
-	index := a.newArchetype(mask)
+func (a *archetypeGraph) prepareNewArchetype(mask Mask, dynMask DynamicMask) int {
+	var components []ComponentID
+	if dynMask != nil {
+		for bit := dynMask.NextBitSet(0); bit < dynMask.TotalBits(); bit = dynMask.NextBitSet(bit + 1) {
+			components = append(components, ComponentID(bit))
+		}
+	} else {
+		for bit := mask.NextBitSet(0); bit < MaskTotalBits; bit = mask.NextBitSet(bit + 1) {
+			components = append(components, ComponentID(bit))
+		}
+	}
+
+	if len(components) >= math.MaxUint16 {
+		panic("too many components in the same archetype")
+	}
+
+	index := a.newArchetype(mask, dynMask, len(components))
 	arch := &a.archetypes[index]
+	if len(components) > 0 {
+		arch.index = make([]uint16, components[len(components)-1]+1)
+	}
 
-	bit := mask.NextBitSet(0)
-	for bit < MaskTotalBits {
-		reg, ok := a.factory.GetByID(ComponentID(bit))
+	// components with data first, then the tags
+	var tags []ComponentID
+	for _, id := range components {
+		reg, ok := a.factory.GetByID(id)
 		if !ok {
 			panic("trying to use components not registered (did you registered it in the ComponentFactory?)")
 		}
-		arch.columns[bit] = reg.NewStorage()
-		bit = mask.NextBitSet(bit + 1)
+		if reg.IsTag() {
+			tags = append(tags, id)
+			continue
+		}
+		arch.storage = append(arch.storage, id)
+		arch.columns = append(arch.columns, reg.NewStorage())
+		arch.index[id] = uint16(len(arch.columns))
+	}
+	for _, id := range tags {
+		arch.columns = append(arch.columns, newTagStorage())
+		arch.index[id] = uint16(len(arch.columns))
 	}
 
 	return index
 }
 
 func (a *archetypeGraph) getUnusedRow(index int, entity EntityID) uint32 {
+	a.version++
 	arch := &a.archetypes[index]
 	row := uint32(len(arch.entities))
 	arch.entities = append(arch.entities, entity)
 
-	bit := arch.mask.NextBitSet(0)
-	for bit < MaskTotalBits {
-		col := arch.columns[bit]
-		if col != nil {
-			col.Expand(uint(row + 1))
-		}
-		bit = arch.mask.NextBitSet(bit + 1)
+	for i := range arch.storage {
+		arch.columns[i].Expand(uint(row + 1))
 	}
 	return row
 }
 
 func (a *archetypeGraph) compressRow(index int, row uint32) {
+	a.version++
 	arch := &a.archetypes[index]
+	if debugChecks {
+		*arch.moves++
+	}
 
 	lastRow := uint(len(arch.entities) - 1)
 	entity := arch.entities[lastRow]
 
-	bit := arch.mask.NextBitSet(0)
-	for bit < MaskTotalBits {
-		col := arch.columns[bit]
-		if col != nil {
-			col.Copy(uint(row), col.Get(lastRow))
-		}
-		bit = arch.mask.NextBitSet(bit + 1)
+	// the last row is zeroed, so the components removed don't keep references for the GC
+	for i := range arch.storage {
+		arch.columns[i].MoveLast(uint(row), lastRow)
 	}
 	arch.entities[row] = entity
 	arch.entities = arch.entities[:lastRow]
-	cache := a.entityMap[entity]
-	cache.row = uint32(row)
-	a.entityMap[entity] = cache
+	a.entityIndex.setRow(entity, uint32(row))
+
+	if a.policy.Automatic {
+		a.compactArchetype(arch)
+	}
 }
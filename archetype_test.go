@@ -14,7 +14,7 @@ func testCheckArchetype(t *testing.T, arch *Archetype, components []ComponentID)
 
 	for _, comp := range components {
 		if arch.mask.IsSet(uint64(comp)) {
-			assert.NotNil(t, arch.columns[comp], "Archetype with wrong component list (want %x, got %d)", MakeComponentMask(components...), arch.mask)
+			assert.NotNil(t, arch.column(comp), "Archetype with wrong component list (want %x, got %d)", MakeComponentMask(components...), arch.mask)
 		}
 	}
 }
@@ -86,6 +86,13 @@ func TestArchetypeGraph(t *testing.T) {
 
 		posTagCtl, _ := ag.Get(e3)
 		testCheckArchetype(t, posTagCtl, []ComponentID{Pos3DCompID, NameTagCompID, ControlledCompID})
+		assert.NotContains(t, posTagCtl.storage, ControlledCompID, "tags should not be in the storage list")
+		assert.Contains(t, posTagCtl.storage, NameTagCompID, "components with data should be in the storage list")
+		assert.Len(t, posTagCtl.columns, 3, "columns should be dense")
+		assert.Equal(t, newTagStorage(), posTagCtl.column(ControlledCompID), "tags should be after the columns with data")
+		assert.Nil(t, posTagCtl.column(Ori3DCompID), "column should be nil for missing components")
+		assert.Nil(t, posTagCtl.column(ControlledCompID+1), "column should be nil for components out of the index")
+		assert.Nil(t, posTagCtl.edges, "edges should be allocated on first connection")
 
 		ag.RemComponent(e1, HealthCompID)
 		archE1, _ := ag.Get(e1)
@@ -147,12 +154,12 @@ func TestArchetypeGraph(t *testing.T) {
 
 		for _, pair := range pairs {
 			arch, row := ag.Get(pair.EntityID)
-			arch.columns[Pos3DCompID].Copy(uint(row), unsafe.Pointer(&pair.Position3D))
+			arch.column(Pos3DCompID).Copy(uint(row), unsafe.Pointer(&pair.Position3D))
 		}
 
 		for _, pair := range pairs {
 			arch, row := ag.Get(pair.EntityID)
-			pos := (*Position3D)(arch.columns[Pos3DCompID].Get(uint(row)))
+			pos := (*Position3D)(arch.column(Pos3DCompID).Get(uint(row)))
 			assert.Equal(t, *pos, pair.Position3D, "values mismatch (want %+v, got %+v)", pair.Position3D, *pos)
 		}
 
@@ -165,13 +172,13 @@ func TestArchetypeGraph(t *testing.T) {
 				ag.AddComponent(pair.EntityID, NameTagCompID)
 			}
 			arch, row := ag.Get(pair.EntityID)
-			pos := (*Position3D)(arch.columns[Pos3DCompID].Get(uint(row)))
+			pos := (*Position3D)(arch.column(Pos3DCompID).Get(uint(row)))
 			assert.Equal(t, *pos, pair.Position3D, "values mismatch (want %+v, got %+v)", pair.Position3D, *pos)
 		}
 
 		for _, pair := range pairs {
 			arch, row := ag.Get(pair.EntityID)
-			pos := (*Position3D)(arch.columns[Pos3DCompID].Get(uint(row)))
+			pos := (*Position3D)(arch.column(Pos3DCompID).Get(uint(row)))
 			assert.Equal(t, *pos, pair.Position3D, "values mismatch (want %+v, got %+v)", pair.Position3D, *pos)
 		}
 	})
@@ -0,0 +1,82 @@
+package ecs_benchmark
+
+import (
+	"math/bits"
+	"testing"
+
+	ecs "github.com/marioolofo/go-gameengine-ecs"
+)
+
+const archetypeTagCount = 16
+
+// GameEngineECSArchetypesBench creates archetypeCount archetypes with one entity each, combining
+// Transform2D with up to archetypeTagCount tags, and iterates over all of them updateCount times.
+// It measures the cost of many small archetypes, as created by procedural component combinations.
+func GameEngineECSArchetypesBench(b *testing.B, archetypeCount, updateCount int) {
+	b.ReportAllocs()
+
+	for n := 0; n < b.N; n++ {
+		world := ecs.NewWorld(uint(archetypeCount))
+		world.Register(ecs.NewComponentRegistry[Transform2D](Transform2DComponentID))
+		for i := 0; i < archetypeTagCount; i++ {
+			world.Register(ecs.NewTagComponentRegistry[struct{}](CustomComponentStartID + ecs.ComponentID(i)))
+		}
+
+		components := make([]ecs.ComponentID, 0, archetypeTagCount+1)
+		for i := 0; i < archetypeCount; i++ {
+			components = append(components[:0], Transform2DComponentID)
+			for tags := uint(i); tags != 0; tags &= tags - 1 {
+				components = append(components, CustomComponentStartID+ecs.ComponentID(bits.TrailingZeros(tags)))
+			}
+			world.NewEntity(components...)
+		}
+
+		mask := ecs.MakeComponentMask(Transform2DComponentID)
+		for i := 0; i < updateCount; i++ {
+			iter := world.Query(mask)
+			for iter.Next() {
+				tr := (*Transform2D)(iter.Component(Transform2DComponentID))
+				tr.rotation += dt
+			}
+		}
+	}
+}
+
+func BenchmarkGameEngineECS_100_archetypes_0_updates(b *testing.B) {
+	GameEngineECSArchetypesBench(b, 100, 0)
+}
+
+func BenchmarkGameEngineECS_1000_archetypes_0_updates(b *testing.B) {
+	GameEngineECSArchetypesBench(b, 1000, 0)
+}
+
+func BenchmarkGameEngineECS_10000_archetypes_0_updates(b *testing.B) {
+	GameEngineECSArchetypesBench(b, 10000, 0)
+}
+
+func BenchmarkGameEngineECS_1000_archetypes_100_updates(b *testing.B) {
+	GameEngineECSArchetypesBench(b, 1000, 100)
+}
+
+// GameEngineECSSpawnBench creates entityCount entities in the same archetype,
+// measuring the cost of growing the columns from empty.
+func GameEngineECSSpawnBench(b *testing.B, entityCount int) {
+	b.ReportAllocs()
+
+	for n := 0; n < b.N; n++ {
+		world := ecs.NewWorld(uint(entityCount))
+		world.Register(ecs.NewComponentRegistry[Transform2D](Transform2DComponentID))
+		world.Register(ecs.NewComponentRegistry[Physics2D](Physics2DComponentID))
+		for i := 0; i < entityCount; i++ {
+			world.NewEntity(Transform2DComponentID, Physics2DComponentID)
+		}
+	}
+}
+
+func BenchmarkGameEngineECS_1_archetypes_1000_entities(b *testing.B) {
+	GameEngineECSSpawnBench(b, 1000)
+}
+
+func BenchmarkGameEngineECS_1_archetypes_100000_entities(b *testing.B) {
+	GameEngineECSSpawnBench(b, 100000)
+}
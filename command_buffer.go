@@ -0,0 +1,129 @@
+package ecs
+
+import (
+	"reflect"
+	"sync/atomic"
+)
+
+/*
+CommandBuffer records structural changes to apply later in the world, when no query is running.
+
+The systems run by the Scheduler can't add or remove entities and components while other systems
+iterate over the world, so they record the changes in their CommandBuffer, applied at the next sync point.
+
+NewEntity returns a placeholder EntityID, only valid in the following commands of the same buffer.
+The entities are created by Apply in the order they were recorded, so their IDs don't depend on
+the order the systems ran, and the placeholders are replaced by the IDs created. The commands with
+placeholders of other buffers are ignored.
+*/
+type CommandBuffer struct {
+	world    World
+	commands []command
+	created  []EntityID // entities created by Apply, indexed by the placeholder ID
+	pending  uint64     // number of placeholders returned by NewEntity
+	id       uint64     // stored in the generation of the placeholders, to tell the buffers apart
+}
+
+// commandPlaceholder marks the EntityIDs returned by CommandBuffer.NewEntity, never used by the world
+const commandPlaceholder = EntityID(EntityFlagsMask)
+
+// commandBuffers is the number of CommandBuffers created, used for their IDs
+var commandBuffers uint64
+
+type commandKind uint8
+
+const (
+	commandNewEntity commandKind = iota
+	commandRemEntity
+	commandAddComponents
+	commandRemComponents
+	commandSetComponent
+)
+
+type command struct {
+	kind       commandKind
+	entity     EntityID
+	components []ComponentID
+	value      interface{}
+}
+
+// NewCommandBuffer returns an empty CommandBuffer for the world
+func NewCommandBuffer(w World) *CommandBuffer {
+	return &CommandBuffer{world: w, id: atomic.AddUint64(&commandBuffers, 1) & EntityMaxGeneration}
+}
+
+// NewEntity returns a placeholder for the entity created with the components when the buffer is applied
+func (c *CommandBuffer) NewEntity(components ...ComponentID) EntityID {
+	entity := commandPlaceholder | MakeEntity(c.pending, c.id)
+	c.pending++
+	c.commands = append(c.commands, command{commandNewEntity, entity, components, nil})
+	return entity
+}
+
+// RemEntity records the removal of the entity
+func (c *CommandBuffer) RemEntity(entity EntityID) {
+	c.commands = append(c.commands, command{commandRemEntity, entity, nil, nil})
+}
+
+// AddComponents records the components to add to the entity
+func (c *CommandBuffer) AddComponents(entity EntityID, components ...ComponentID) {
+	c.commands = append(c.commands, command{commandAddComponents, entity, components, nil})
+}
+
+// RemComponents records the components to remove from the entity
+func (c *CommandBuffer) RemComponents(entity EntityID, components ...ComponentID) {
+	c.commands = append(c.commands, command{commandRemComponents, entity, components, nil})
+}
+
+// SetComponent records the value, a pointer to the component type, to copy to the entity's component.
+// The value is copied by this call, so the pointer can be reused after it returns
+func (c *CommandBuffer) SetComponent(entity EntityID, component ComponentID, value interface{}) {
+	if v := reflect.ValueOf(value); v.Kind() == reflect.Ptr && !v.IsNil() {
+		copied := reflect.New(v.Elem().Type())
+		copied.Elem().Set(v.Elem())
+		value = copied.Interface()
+	}
+	c.commands = append(c.commands, command{commandSetComponent, entity, []ComponentID{component}, value})
+}
+
+// Len returns the number of commands recorded
+func (c *CommandBuffer) Len() int {
+	return len(c.commands)
+}
+
+// Apply executes the commands in the order they were recorded and clears the buffer
+func (c *CommandBuffer) Apply() {
+	for i := range c.commands {
+		cmd := &c.commands[i]
+		entity, ok := c.resolve(cmd.entity)
+		switch {
+		case cmd.kind == commandNewEntity:
+			c.created = append(c.created, c.world.NewEntity(cmd.components...))
+		case !ok:
+		case cmd.kind == commandRemEntity:
+			c.world.RemEntity(entity)
+		case cmd.kind == commandAddComponents:
+			c.world.AddComponents(entity, cmd.components...)
+		case cmd.kind == commandRemComponents:
+			c.world.RemComponents(entity, cmd.components...)
+		case cmd.kind == commandSetComponent:
+			c.world.SetComponent(entity, cmd.components[0], cmd.value)
+		}
+		*cmd = command{}
+	}
+	c.commands = c.commands[:0]
+	c.created = c.created[:0]
+	c.pending = 0
+}
+
+// resolve returns the entity created for the placeholder, or the entity itself.
+// Returns false for the placeholders of other buffers
+func (c *CommandBuffer) resolve(entity EntityID) (EntityID, bool) {
+	if entity.Flags() != commandPlaceholder {
+		return entity, true
+	}
+	if index := entity.ID(); entity.Gen() == c.id && index < uint64(len(c.created)) {
+		return c.created[index], true
+	}
+	return entity, false
+}
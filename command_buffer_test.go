@@ -0,0 +1,49 @@
+package ecs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommandBuffer(t *testing.T) {
+	const (
+		PositionCompID ComponentID = iota
+		VelocityCompID
+	)
+	type Position struct{ x, y float32 }
+	type Velocity struct{ x, y float32 }
+
+	w := NewWorld(0)
+	w.Register(NewComponentRegistry[Position](PositionCompID))
+	w.Register(NewComponentRegistry[Velocity](VelocityCompID))
+
+	moving := w.NewEntity(PositionCompID, VelocityCompID)
+	removed := w.NewEntity(PositionCompID)
+
+	cmd := NewCommandBuffer(w)
+	spawned := cmd.NewEntity(PositionCompID)
+	cmd.AddComponents(spawned, VelocityCompID)
+	cmd.SetComponent(spawned, VelocityCompID, &Velocity{1, 2})
+	cmd.RemComponents(moving, VelocityCompID)
+	cmd.RemEntity(removed)
+	cmd.RemEntity(NewCommandBuffer(w).NewEntity())
+	assert.Equal(t, 6, cmd.Len(), "expected Len to count the commands recorded")
+	assert.False(t, w.IsAlive(spawned), "expected the commands to wait for Apply")
+
+	cmd.Apply()
+	assert.Zero(t, cmd.Len(), "expected Apply to clear the buffer")
+	assert.True(t, w.Component(moving, VelocityCompID) == nil, "expected RemComponents to remove the components")
+	assert.NotNil(t, w.Component(moving, PositionCompID), "expected RemComponents to keep the other components")
+	assert.False(t, w.IsAlive(removed), "expected RemEntity to remove the entity")
+	assert.EqualValues(t, 2, w.Stats().EntityPool.Alive, "expected placeholders of other buffers to be ignored")
+
+	query := w.Query(MakeComponentMask(PositionCompID, VelocityCompID))
+	assert.True(t, query.Next(), "expected NewEntity to create the entity")
+	assert.Equal(t, Velocity{1, 2}, *(*Velocity)(query.Component(VelocityCompID)), "expected the commands to use the entity created")
+
+	next := cmd.NewEntity()
+	cmd.Apply()
+	assert.True(t, w.IsAlive(query.Entity()), "expected the entities created to stay alive")
+	assert.EqualValues(t, 0, next.ID(), "expected Apply to restart the placeholders")
+}
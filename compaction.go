@@ -0,0 +1,201 @@
+package ecs
+
+import "io"
+
+/*
+CompactionPolicy controls when the ArchetypeGraph releases the memory of underused archetypes.
+
+An archetype is underused when the number of entities is less than ShrinkThreshold times its capacity.
+The entity list and each column are checked by their own capacity. When compacted, the capacity is reduced to the number of entities plus Headroom times this number,
+but never less than MinCapacity. The gap between ShrinkThreshold and the capacity kept by Headroom
+avoids shrinking and growing the same archetype every frame.
+
+Archetypes without entities can be removed from the graph by ArchetypeGraph.RemEmptyArchetypes,
+or by ArchetypeGraph.Maintain after they stay empty for ReclaimAfter calls.
+*/
+type CompactionPolicy struct {
+	// MinCapacity is the capacity in entities kept by the archetypes after compaction
+	MinCapacity uint
+	// ShrinkThreshold is the usage ratio (entities / capacity) bellow which the archetype is compacted
+	ShrinkThreshold float32
+	// Headroom is the extra capacity, as a ratio of the entities, kept after compaction
+	Headroom float32
+	// Automatic enables the compaction when entities leave the archetypes.
+	// When disabled, the memory is only released by ArchetypeGraph.Compact
+	Automatic bool
+	// ReclaimAfter is the number of Maintain calls an archetype must stay empty before it's removed.
+	// Zero disables the removal by Maintain
+	ReclaimAfter uint
+}
+
+// DefaultCompactionPolicy is the policy used by the archetype graphs until SetCompactionPolicy is called
+var DefaultCompactionPolicy = CompactionPolicy{
+	MinCapacity:     ComponentStorageInitialCap,
+	ShrinkThreshold: 0.25,
+	Headroom:        0.5,
+	Automatic:       false,
+	ReclaimAfter:    0,
+}
+
+func (a *archetypeGraph) Compact() {
+	for i := range a.archetypes {
+		a.compactArchetype(&a.archetypes[i])
+	}
+}
+
+func (a *archetypeGraph) SetCompactionPolicy(policy CompactionPolicy) {
+	a.policy = policy
+}
+
+func (a *archetypeGraph) RemEmptyArchetypes() {
+	a.remArchetypes(func(arch *Archetype) bool {
+		return len(arch.entities) == 0
+	})
+}
+
+func (a *archetypeGraph) Maintain() {
+	if a.policy.ReclaimAfter == 0 {
+		return
+	}
+
+	for i := range a.archetypes {
+		arch := &a.archetypes[i]
+		if len(arch.entities) > 0 {
+			arch.idle = 0
+		} else if arch.idle < a.policy.ReclaimAfter {
+			arch.idle++
+		}
+	}
+
+	a.remArchetypes(func(arch *Archetype) bool {
+		return len(arch.entities) == 0 && arch.idle >= a.policy.ReclaimAfter
+	})
+}
+
+/*
+remArchetypes removes the archetypes selected by the filter, except the root archetype.
+
+The remaining archetypes keep their order and are copied to a new slice without the holes, so
+the indices in the archetype maps, entity map and edges are remapped, and the edges to the removed
+archetypes are discarded to be created again when needed. The old slice is not changed, so the
+queries iterating over it never see the archetypes in other positions.
+*/
+func (a *archetypeGraph) remArchetypes(filter func(*Archetype) bool) {
+	remap := make([]int, len(a.archetypes))
+	count := 0
+	for i := range a.archetypes {
+		arch := &a.archetypes[i]
+		if i > 0 && filter(arch) {
+			remap[i] = -1
+			a.releaseArchetype(arch)
+			continue
+		}
+		remap[i] = count
+		count++
+	}
+	if count == len(a.archetypes) {
+		return
+	}
+
+	a.version++
+	a.layout++
+
+	// the remaining archetypes are copied to a new slice, as the queries keep the old one
+	archetypes := make([]Archetype, 0, cap(a.archetypes))
+	for i := range a.archetypes {
+		index := remap[i]
+		if index < 0 {
+			continue
+		}
+		arch := a.archetypes[i]
+		arch.stamp = a.layout
+		arch.edges = remapEdges(arch.edges, remap)
+		if index != i {
+			for row, entity := range arch.entities {
+				a.entityIndex.set(entity, archetypeEntityIndex{index, uint32(row)})
+			}
+		}
+		archetypes = append(archetypes, arch)
+	}
+	a.archetypes = archetypes
+
+	if a.unconstrained {
+		a.dynArchetypeMap = make(map[string]int, count)
+		for i := range a.archetypes {
+			a.dynArchetypeMap[a.archetypes[i].dynMask.Key()] = i
+		}
+	} else {
+		a.archetypeMap = make(map[Mask]int, count)
+		for i := range a.archetypes {
+			a.archetypeMap[a.archetypes[i].mask] = i
+		}
+	}
+}
+
+// releaseArchetype resets the columns of the archetype, except the singletons that are shared by all the archetypes,
+// and closes the columns with files, like the ones of NewMmapComponentRegistry
+func (a *archetypeGraph) releaseArchetype(arch *Archetype) {
+	for i, id := range arch.storage {
+		if reg, ok := a.factory.GetByID(id); ok && reg.kind != componentKindSingleton {
+			arch.columns[i].Reset()
+			if closer, ok := arch.columns[i].(io.Closer); ok {
+				if err := closer.Close(); err != nil {
+					panic(err)
+				}
+			}
+		}
+	}
+}
+
+// remapEdges returns the edges pointing to the new archetype indices, discarding the edges to removed archetypes
+func remapEdges(edges map[ComponentID]ArchEdge, remap []int) map[ComponentID]ArchEdge {
+	for id, edge := range edges {
+		if edge.add > -1 {
+			edge.add = remap[edge.add]
+		}
+		if edge.rem > -1 {
+			edge.rem = remap[edge.rem]
+		}
+		if edge.add < 0 && edge.rem < 0 {
+			delete(edges, id)
+		} else {
+			edges[id] = edge
+		}
+	}
+	return edges
+}
+
+// compactArchetype shrinks the entity list and each column of the archetype that is underused,
+// as the columns can grow in a different pace of the entity list
+func (a *archetypeGraph) compactArchetype(arch *Archetype) {
+	count := uint(len(arch.entities))
+	if target, ok := a.compactTarget(count, uint(cap(arch.entities))); ok {
+		a.version++
+		entities := make([]EntityID, count, target)
+		copy(entities, arch.entities)
+		arch.entities = entities
+	}
+
+	for i := range arch.storage {
+		if target, ok := a.compactTarget(count, arch.columns[i].Stats().Cap); ok {
+			a.version++
+			arch.columns[i].Shrink(target)
+		}
+	}
+}
+
+// compactTarget returns the capacity to keep for count items, or false if the capacity is not underused
+func (a *archetypeGraph) compactTarget(count, capacity uint) (uint, bool) {
+	if capacity <= a.policy.MinCapacity {
+		return 0, false
+	}
+	if float32(count) >= float32(capacity)*a.policy.ShrinkThreshold {
+		return 0, false
+	}
+
+	target := count + uint(float32(count)*a.policy.Headroom)
+	if target < a.policy.MinCapacity {
+		target = a.policy.MinCapacity
+	}
+	return target, target < capacity
+}
@@ -0,0 +1,165 @@
+package ecs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompaction(t *testing.T) {
+	const (
+		PositionCompID ComponentID = iota
+		ProjectileCompID
+	)
+	type Position struct{ x, y float32 }
+	type Projectile struct{}
+
+	testCapacity := func(w World, e EntityID) (uint, uint) {
+		arch, _ := w.(*world).archGraph.Get(e)
+		return uint(cap(arch.entities)), arch.column(PositionCompID).Stats().Cap
+	}
+
+	spawn := func(w World, count int) []EntityID {
+		entities := make([]EntityID, count)
+		for i := range entities {
+			entities[i] = w.NewEntity(PositionCompID, ProjectileCompID)
+			w.SetComponent(entities[i], PositionCompID, &Position{float32(i), float32(i)})
+		}
+		return entities
+	}
+
+	t.Run("Compact", func(t *testing.T) {
+		w := NewWorld(0)
+		w.Register(NewComponentRegistry[Position](PositionCompID))
+		w.Register(NewComponentRegistry[Projectile](ProjectileCompID))
+
+		entities := spawn(w, 50000)
+		for _, e := range entities[100:] {
+			w.RemEntity(e)
+		}
+
+		entityCap, columnCap := testCapacity(w, entities[0])
+		assert.Greater(t, entityCap, uint(50000), "archetypes should not shrink without compaction")
+		assert.Greater(t, columnCap, uint(50000), "columns should not shrink without compaction")
+
+		w.Compact()
+		entityCap, columnCap = testCapacity(w, entities[0])
+		assert.EqualValues(t, DefaultCompactionPolicy.MinCapacity, entityCap, "Compact should shrink the entity list to MinCapacity")
+		assert.EqualValues(t, DefaultCompactionPolicy.MinCapacity, columnCap, "Compact should shrink the columns to MinCapacity")
+
+		for i, e := range entities[:100] {
+			pos := (*Position)(w.Component(e, PositionCompID))
+			assert.Equal(t, Position{float32(i), float32(i)}, *pos, "Compact should keep the component values")
+		}
+
+		w.Compact()
+		entityCap, _ = testCapacity(w, entities[0])
+		assert.EqualValues(t, DefaultCompactionPolicy.MinCapacity, entityCap, "Compact should keep MinCapacity")
+
+		arch, _ := w.(*world).archGraph.Get(entities[0])
+		arch.column(PositionCompID).Expand(50000)
+		w.Compact()
+		entityCap, columnCap = testCapacity(w, entities[0])
+		assert.EqualValues(t, DefaultCompactionPolicy.MinCapacity, entityCap, "Compact should keep MinCapacity")
+		assert.EqualValues(t, DefaultCompactionPolicy.MinCapacity, columnCap, "Compact should shrink the columns bigger than the entity list")
+	})
+
+	t.Run("Automatic", func(t *testing.T) {
+		w := NewWorld(0)
+		w.Register(NewComponentRegistry[Position](PositionCompID))
+		w.Register(NewComponentRegistry[Projectile](ProjectileCompID))
+		w.SetCompactionPolicy(CompactionPolicy{
+			MinCapacity:     16,
+			ShrinkThreshold: 0.25,
+			Headroom:        1,
+			Automatic:       true,
+		})
+
+		entities := spawn(w, 10000)
+		for _, e := range entities[1000:] {
+			w.RemEntity(e)
+		}
+
+		entityCap, columnCap := testCapacity(w, entities[0])
+		assert.LessOrEqual(t, entityCap, uint(4000), "automatic compaction should shrink the archetype")
+		assert.GreaterOrEqual(t, entityCap, uint(2000), "automatic compaction should keep the headroom")
+		assert.LessOrEqual(t, columnCap, uint(4000), "automatic compaction should shrink the columns")
+		assert.GreaterOrEqual(t, columnCap, uint(2000), "automatic compaction should keep the headroom of the columns")
+
+		for i, e := range entities[:1000] {
+			pos := (*Position)(w.Component(e, PositionCompID))
+			assert.Equal(t, Position{float32(i), float32(i)}, *pos, "automatic compaction should keep the component values")
+		}
+
+		for _, e := range entities[:1000] {
+			w.RemEntity(e)
+		}
+		entityCap, _ = testCapacity(w, w.NewEntity(PositionCompID, ProjectileCompID))
+		assert.EqualValues(t, 16, entityCap, "automatic compaction should stop at MinCapacity")
+	})
+
+	t.Run("RemEmptyArchetypes", func(t *testing.T) {
+		for _, w := range []World{NewWorld(0), NewUnconstrainedWorld(0)} {
+			w.Register(NewComponentRegistry[Position](PositionCompID))
+			w.Register(NewComponentRegistry[Projectile](ProjectileCompID))
+			graph := w.(*world).archGraph.(*archetypeGraph)
+
+			moved := w.NewEntity(PositionCompID)
+			w.SetComponent(moved, PositionCompID, &Position{1, 2})
+			removed := w.NewEntity(ProjectileCompID)
+			w.AddComponent(moved, ProjectileCompID)
+			w.RemEntity(removed)
+			assert.Len(t, graph.archetypes, 4, "expected archetypes for every combination used")
+
+			live := w.Query(MakeComponentMask(PositionCompID))
+			assert.True(t, live.Next() && live.Entity() == moved, "expected the query to find the entity")
+			w.RemEmptyArchetypes()
+			assert.Len(t, graph.archetypes, 2, "RemEmptyArchetypes should keep only the root and archetypes with entities")
+			assert.Equal(t, moved, live.Entity(), "queries started before RemEmptyArchetypes should keep their archetypes")
+			assert.False(t, live.Next(), "queries started before RemEmptyArchetypes should not see the archetypes again")
+			assert.Equal(t, Position{1, 2}, *(*Position)(w.Component(moved, PositionCompID)), "RemEmptyArchetypes should keep the entity components")
+
+			query := w.Query(MakeComponentMask(PositionCompID, ProjectileCompID))
+			assert.True(t, query.Next() && query.Entity() == moved, "queries should find the entities after RemEmptyArchetypes")
+
+			w.RemComponent(moved, ProjectileCompID)
+			w.AddComponent(moved, ProjectileCompID)
+			w.RemComponent(moved, PositionCompID)
+			assert.Len(t, graph.archetypes, 4, "expected archetypes to be created again when needed")
+			assert.True(t, w.Component(moved, PositionCompID) == nil, "expected removed component")
+			assert.NotNil(t, w.Component(moved, ProjectileCompID), "expected the entity to keep the component")
+
+			w.RemEntity(moved)
+			w.RemEmptyArchetypes()
+			assert.Len(t, graph.archetypes, 1, "RemEmptyArchetypes should keep the root archetype")
+		}
+	})
+
+	t.Run("Maintain", func(t *testing.T) {
+		w := NewWorld(0)
+		w.Register(NewComponentRegistry[Position](PositionCompID))
+		w.Register(NewComponentRegistry[Projectile](ProjectileCompID))
+		graph := w.(*world).archGraph.(*archetypeGraph)
+
+		w.RemEntity(w.NewEntity(PositionCompID))
+		w.Maintain()
+		assert.Len(t, graph.archetypes, 2, "Maintain should not remove archetypes without ReclaimAfter")
+
+		policy := DefaultCompactionPolicy
+		policy.ReclaimAfter = 3
+		w.SetCompactionPolicy(policy)
+
+		w.Maintain()
+		w.Maintain()
+		e := w.NewEntity(PositionCompID)
+		w.Maintain()
+		assert.Len(t, graph.archetypes, 2, "Maintain should not remove archetypes with entities")
+
+		w.RemEntity(e)
+		w.Maintain()
+		w.Maintain()
+		assert.Len(t, graph.archetypes, 2, "Maintain should count the frames since the archetype became empty")
+		w.Maintain()
+		assert.Len(t, graph.archetypes, 1, "Maintain should remove archetypes empty for ReclaimAfter frames")
+	})
+}
@@ -11,3 +11,12 @@ func MakeComponentMask(bits ...ComponentID) Mask {
 	}
 	return mask
 }
+
+// MakeDynamicComponentMask returns a DynamicMask set with the bits set for the ComponentID list
+func MakeDynamicComponentMask(bits ...ComponentID) DynamicMask {
+	mask := DynamicMask{}
+	for _, bit := range bits {
+		mask.Set(uint64(bit))
+	}
+	return mask
+}
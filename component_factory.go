@@ -5,9 +5,10 @@ import (
 )
 
 const (
-	// the maximum number of components that can be stored
+	// the maximum number of components that can be stored (see NewUnconstrainedComponentFactory for no limit)
 	MaxComponentCount uint = 256
-	// initial number of elements in the component Storage
+	// number of elements kept in the component Storage by the DefaultCompactionPolicy.
+	// The columns start empty and double their capacity until ComponentStorageIncrement
 	ComponentStorageInitialCap uint = 1024
 	// number of elements that must be added to the Storage when needed
 	ComponentStorageIncrement uint = 2048
@@ -32,23 +33,50 @@ type ComponentFactory interface {
 
 type componentFactory struct {
 	refs       map[reflect.Type]uint
-	components [MaxComponentCount]ComponentRegistry
-	mask       Mask
+	components []ComponentRegistry
+	mask       DynamicMask
+	limit      uint
 }
 
 // NewComponentFactory returns an implementation of the ComponentFactory interface
+// for component IDs lower than MaxComponentCount
 func NewComponentFactory() ComponentFactory {
 	return &componentFactory{
 		refs:       make(map[reflect.Type]uint),
-		components: [MaxComponentCount]ComponentRegistry{},
-		mask:       Mask{},
+		components: make([]ComponentRegistry, MaxComponentCount),
+		mask:       make(DynamicMask, MaxComponentCount/64),
+		limit:      MaxComponentCount,
+	}
+}
+
+// NewUnconstrainedComponentFactory returns an implementation of the ComponentFactory interface
+// without limit for the component IDs, to be used with NewUnconstrainedArchetypeGraph
+func NewUnconstrainedComponentFactory() ComponentFactory {
+	return &componentFactory{
+		refs:       make(map[reflect.Type]uint),
+		components: make([]ComponentRegistry, 0, MaxComponentCount),
+		mask:       make(DynamicMask, MaxComponentCount/64),
 	}
 }
 
 func (c *componentFactory) Register(comp ComponentRegistry) {
+	if c.limit > 0 && comp.ID >= c.limit {
+		panic("Component ID out of range (did you mean NewUnconstrainedComponentFactory instead?)")
+	}
 	if c.mask.IsSet(uint64(comp.ID)) {
 		panic("Component already registered")
 	}
+	if comp.ID >= uint(len(c.components)) {
+		grown := make([]ComponentRegistry, comp.ID+1)
+		copy(grown, c.components)
+		c.components = grown
+	}
+
+	// zero sized components created without NewComponentRegistry are tags too
+	if comp.kind == componentKindDefault && comp.Type != nil && comp.Type.Size() == 0 {
+		comp.kind = componentKindTag
+		comp.NewStorage = newTagStorage
+	}
 
 	c.refs[comp.Type] = comp.ID
 	c.components[comp.ID] = comp
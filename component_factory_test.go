@@ -1,6 +1,7 @@
 package ecs
 
 import (
+	"fmt"
 	"reflect"
 	"testing"
 	"unsafe"
@@ -62,7 +63,9 @@ func TestComponentFactory(t *testing.T) {
 
 	storage = configComp.NewStorage()
 	assert.NotNil(t, storage, "comp.NewStorage() should return a valid Storage")
+	assert.Zero(t, storage.Stats().Cap, "comp.NewStorage() should return an empty Storage")
 
+	storage.Expand(1)
 	v := storage.Get(0)
 	assert.False(t, v == unsafe.Pointer(nil), "storage.Get should return valid pointer even for zero sized structs")
 
@@ -94,3 +97,121 @@ func TestComponentFactory(t *testing.T) {
 	assert.True(t, stats.Cap == 1, "singletonStorage.Cap should be set to one")
 	assert.True(t, uintptr(stats.ItemSize) == unsafe.Sizeof(input), "singletonStorage.ItemSize should be the size of the struct")
 }
+
+func TestComponentFactoryInterfaces(t *testing.T) {
+	const (
+		ScriptCompID = iota
+		LabelCompID
+		ContextCompID
+	)
+	type Script interface{ Run() }
+
+	var regs []ComponentRegistry
+	assert.NotPanics(t, func() {
+		regs = []ComponentRegistry{
+			NewComponentRegistry[any](ScriptCompID),
+			NewSparseComponentRegistry[fmt.Stringer](LabelCompID),
+			NewSingletonComponentRegistry[Script](ContextCompID),
+		}
+	}, "interface types should be valid components")
+
+	w := NewWorld(0)
+	for _, reg := range regs {
+		assert.False(t, reg.IsTag(), "interface types should not be registered as tags")
+		assert.False(t, reg.PointerFree(), "interface types have pointers")
+		assert.EqualValues(t, reg.Size(), reg.NewStorage().Stats().ItemSize, "Stats should work for interface types")
+		w.Register(reg)
+	}
+
+	e := w.NewEntity(ScriptCompID, LabelCompID, ContextCompID)
+	var value any = "script"
+	assert.True(t, w.SetComponent(e, ScriptCompID, &value), "SetComponent should accept interface values")
+	assert.Equal(t, "script", *(*any)(w.Component(e, ScriptCompID)), "expected the interface value")
+}
+
+func TestComponentFactoryTags(t *testing.T) {
+	const (
+		ControllableCompID = iota
+		EnemyCompID
+		MarkerCompID
+		HealthCompID
+	)
+	type Controllable struct{}
+	type Enemy struct{}
+	type Marker struct{}
+	type Health struct{ value float32 }
+
+	factory := NewComponentFactory()
+
+	factory.Register(NewComponentRegistry[Controllable](ControllableCompID))
+	factory.Register(NewTagComponentRegistry[Enemy](EnemyCompID))
+	factory.Register(ComponentRegistry{
+		ID:         MarkerCompID,
+		Type:       reflect.TypeOf(Marker{}),
+		NewStorage: func() Storage { return NewStorage[Marker](1, 1) },
+	})
+	factory.Register(NewComponentRegistry[Health](HealthCompID))
+
+	assert.Panics(t, func() {
+		NewTagComponentRegistry[Health](HealthCompID)
+	}, "should panic when declaring components with data as tags")
+
+	for _, id := range []ComponentID{ControllableCompID, EnemyCompID, MarkerCompID} {
+		comp, _ := factory.GetByID(id)
+		assert.True(t, comp.IsTag(), "zero sized components should be registered as tags (component %d)", id)
+
+		storage := comp.NewStorage()
+		assert.Equal(t, storage, newTagStorage(), "tags should use the shared tag storage")
+		assert.False(t, storage.Get(0) == unsafe.Pointer(nil), "tag storage should return valid pointer")
+		assert.True(t, storage.Set(0, &Enemy{}), "tag storage should accept any value")
+	}
+
+	comp, _ := factory.GetByID(HealthCompID)
+	assert.False(t, comp.IsTag(), "components with data should not be registered as tags")
+
+	storage := newTagStorage()
+	storage.Copy(0, nil)
+	storage.Expand(1000)
+	storage.Shrink(0)
+	storage.Reset()
+	stats := storage.Stats()
+	assert.True(t, stats.Cap == 0 && stats.ItemSize == 0, "tag storage should not allocate memory")
+}
+
+func TestComponentFactoryNoScan(t *testing.T) {
+	const (
+		PositionCompID = iota
+		NameCompID
+		TagCompID
+	)
+	type Position struct{ x, y float32 }
+	type Name struct{ value string }
+	type Tag struct{}
+
+	position := NewNoScanComponentRegistry[Position](PositionCompID)
+	name := NewNoScanComponentRegistry[Name](NameCompID)
+	tag := NewNoScanComponentRegistry[Tag](TagCompID)
+
+	assert.True(t, position.PointerFree(), "structs without pointers should be classified as pointer-free")
+	assert.False(t, name.PointerFree(), "structs with strings should not be classified as pointer-free")
+	assert.False(t, ComponentRegistry{}.PointerFree(), "registries without type should not be classified as pointer-free")
+	assert.True(t, tag.IsTag(), "zero sized types should be registered as tags")
+
+	_, ok := position.NewStorage().(*storageNoScan[Position])
+	assert.True(t, ok, "pointer-free components should be allocated outside the heap")
+	_, ok = name.NewStorage().(*storage[Name])
+	assert.True(t, ok, "components with pointers should be allocated in the heap")
+
+	factory := NewComponentFactory()
+	factory.Register(position)
+	factory.Register(name)
+	graph := NewArchetypeGraph(factory)
+	graph.Add(1, PositionCompID, NameCompID)
+
+	arch, _ := graph.Get(1)
+	stats := arch.MemoryStats()
+	nameBytes := uint(unsafe.Sizeof(Name{}) * uintptr(arch.column(NameCompID).Stats().Cap))
+	positionBytes := uint(unsafe.Sizeof(Position{}) * uintptr(arch.column(PositionCompID).Stats().Cap))
+	assert.Equal(t, nameBytes+positionBytes, stats.Bytes, "MemoryStats should return the memory of all columns")
+	assert.Equal(t, nameBytes, stats.ScanBytes, "MemoryStats should return only the memory with pointers")
+}
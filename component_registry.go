@@ -2,9 +2,21 @@ package ecs
 
 import (
 	"reflect"
+	"sync"
 	"unsafe"
 )
 
+// componentKind defines how the component data is stored in the archetypes
+type componentKind uint8
+
+const (
+	componentKindDefault   componentKind = iota // one value per entity, stored in the archetype columns
+	componentKindSingleton                      // one value for all the entities
+	componentKindTag                            // no value, only used in the archetype masks
+	componentKindShared                         // one value for every group of entities with the same value
+	componentKindSparse                         // one value per entity, stored in a sparse set outside the archetypes
+)
+
 /*
 ComponentRegistry defines a component ID, it's type and how to create a new Storage for it.
 */
@@ -16,28 +28,122 @@ type ComponentRegistry struct {
 	// NewStorage is a factory function that returns an implementation of the Storage interface
 	NewStorage func() Storage
 	singleton  Storage
+	kind       componentKind
+}
+
+// IsTag returns true if the component has no data and don't need storage
+func (c ComponentRegistry) IsTag() bool {
+	return c.kind == componentKindTag
 }
 
-// NewComponentRegistry[T] returns a ComponentRegistry definition for the type T and id
+// IsShared returns true if the component value is shared by the entities with the same value
+func (c ComponentRegistry) IsShared() bool {
+	return c.kind == componentKindShared
+}
+
+// IsSparse returns true if the component is stored in a sparse set instead of the archetypes
+func (c ComponentRegistry) IsSparse() bool {
+	return c.kind == componentKindSparse
+}
+
+// PointerFree returns true if the component type don't have pointers, so the GC don't need to scan it
+func (c ComponentRegistry) PointerFree() bool {
+	return c.Type != nil && isPointerFree(c.Type)
+}
+
+// NewComponentRegistry[T] returns a ComponentRegistry definition for the type T and id.
+// Zero sized types are detected and registered as tags, see NewTagComponentRegistry[T]
 func NewComponentRegistry[T any](id ComponentID) ComponentRegistry {
-	var t T
-	typeOf := reflect.TypeOf(t)
+	typeOf := typeFor[T]()
+
+	if typeOf.Size() == 0 {
+		return NewTagComponentRegistry[T](id)
+	}
 
 	return ComponentRegistry{
 		id,
 		typeOf,
 		func() Storage {
-			return NewStorage[T](ComponentStorageInitialCap, ComponentStorageIncrement)
+			return NewStorage[T](0, ComponentStorageIncrement)
 		},
 		nil,
+		componentKindDefault,
+	}
+}
+
+/*
+NewNoScanComponentRegistry[T] returns a ComponentRegistry definition for the type T and id that
+allocates the columns outside the Go heap when T is pointer-free, using NewStorageNoScan[T].
+Big columns in this memory are never scanned by the GC and don't trigger collections.
+
+The component pointers returned by the World stay readable after the columns grow, as with
+NewComponentRegistry[T], but the old memory is unmapped when the columns are compacted or removed,
+by World.Compact, World.Maintain, World.RemEmptyArchetypes and the automatic compaction, so the
+pointers taken before them must not be used anymore, or the program crashes instead of reading old values.
+
+Types with pointers use the same Storage as NewComponentRegistry[T], as the GC needs to know about them.
+*/
+func NewNoScanComponentRegistry[T any](id ComponentID) ComponentRegistry {
+	reg := NewComponentRegistry[T](id)
+	if reg.kind == componentKindDefault && reg.PointerFree() {
+		reg.NewStorage = func() Storage {
+			return NewStorageNoScan[T](0, ComponentStorageIncrement)
+		}
+	}
+	return reg
+}
+
+// NewTagComponentRegistry[T] returns a ComponentRegistry definition for the tag T and id.
+// Tags participate in the archetype masks, but don't allocate memory and are ignored when
+// entities move between archetypes. T must be zero sized, like struct{}, or this function panics.
+func NewTagComponentRegistry[T any](id ComponentID) ComponentRegistry {
+	typeOf := typeFor[T]()
+
+	if typeOf.Size() != 0 {
+		panic("tag components can't have data (use NewComponentRegistry instead)")
+	}
+
+	return ComponentRegistry{
+		id,
+		typeOf,
+		newTagStorage,
+		nil,
+		componentKindTag,
+	}
+}
+
+/*
+NewSparseComponentRegistry[T] returns a ComponentRegistry definition for the type T and id,
+stored in a sparse set keyed by the entity instead of the archetype columns.
+
+Adding and removing sparse components are O(1) and never move the entity between archetypes,
+so it's a good fit for components that are frequently toggled, like Stunned or Hovered.
+Queries with sparse components are slower, as every entity found by the other components
+of the mask is tested against the sparse sets.
+*/
+func NewSparseComponentRegistry[T any](id ComponentID) ComponentRegistry {
+	typeOf := typeFor[T]()
+
+	newStorage := func() Storage {
+		return NewStorage[T](0, ComponentStorageIncrement)
+	}
+	if typeOf.Size() == 0 {
+		newStorage = newTagStorage
+	}
+
+	return ComponentRegistry{
+		id,
+		typeOf,
+		newStorage,
+		nil,
+		componentKindSparse,
 	}
 }
 
 // NewSingletonComponentRegistry[T] returns a ComponentRegistry definition for the type T and id,
 // with the difference that the NewStorage always returns the same Storage for every call.
 func NewSingletonComponentRegistry[T any](id ComponentID) ComponentRegistry {
-	var t T
-	typeOf := reflect.TypeOf(t)
+	typeOf := typeFor[T]()
 
 	storage := newSingletonStorage[T]()
 
@@ -48,6 +154,33 @@ func NewSingletonComponentRegistry[T any](id ComponentID) ComponentRegistry {
 			return storage
 		},
 		storage,
+		componentKindSingleton,
+	}
+}
+
+/*
+NewSharedComponentRegistry[T] returns a ComponentRegistry definition for the type T and id,
+where the entities with the same value share the same memory for it, like a Material or TeamSettings.
+
+Every distinct value is stored once for all the worlds using this registry, released when no entity
+uses it anymore, and the archetypes only keep the pointer to the value for every entity. Use World.SetComponent to change the value of one entity,
+as writing to the pointer returned by World.Component changes the value for the whole group.
+
+To iterate over every distinct value and then over its entities, see QueryCursor.GroupBy.
+*/
+func NewSharedComponentRegistry[T comparable](id ComponentID) ComponentRegistry {
+	typeOf := typeFor[T]()
+
+	values := newSharedValues[T]()
+
+	return ComponentRegistry{
+		id,
+		typeOf,
+		func() Storage {
+			return newSharedStorage(values, 0, ComponentStorageIncrement)
+		},
+		nil,
+		componentKindShared,
 	}
 }
 
@@ -76,6 +209,15 @@ func (s *singletonStorage[T]) Copy(pos uint, ptr unsafe.Pointer) {
 	s.value = *val
 }
 
+// CopyRange keeps the last value copied, as all the rows share the same value
+func (s *singletonStorage[T]) CopyRange(pos uint, src Storage, from, count uint) {
+	if count > 0 {
+		s.Copy(pos, src.Get(from+count-1))
+	}
+}
+
+func (s *singletonStorage[T]) MoveLast(uint, uint) {}
+
 func (s *singletonStorage[T]) Shrink(uint) {}
 
 func (s *singletonStorage[T]) Expand(uint) {}
@@ -86,12 +228,248 @@ func (s *singletonStorage[T]) Reset() {
 }
 
 func (s singletonStorage[T]) Stats() StorageStats {
-	var t T
-	typeOf := reflect.TypeOf(t)
+	typeOf := typeFor[T]()
 
 	return StorageStats{
 		typeOf,
 		uint(typeOf.Size()),
 		1,
+		scanBytes(typeOf, 1),
+	}
+}
+
+// tagStorage is the Storage shared by all the tag components.
+// It don't allocate memory and Get always returns the address of the same zero sized value
+type tagStorage struct{}
+
+var tagValue struct{}
+
+func newTagStorage() Storage {
+	return tagStorage{}
+}
+
+func (tagStorage) Get(uint) unsafe.Pointer {
+	return unsafe.Pointer(&tagValue)
+}
+
+func (tagStorage) Set(uint, interface{}) bool {
+	return true
+}
+
+func (tagStorage) Copy(uint, unsafe.Pointer) {}
+
+func (tagStorage) CopyRange(uint, Storage, uint, uint) {}
+
+func (tagStorage) MoveLast(uint, uint) {}
+
+func (tagStorage) Shrink(uint) {}
+
+func (tagStorage) Expand(uint) {}
+
+func (tagStorage) Reset() {}
+
+func (tagStorage) Stats() StorageStats {
+	return StorageStats{
+		reflect.TypeOf(tagValue),
+		0,
+		0,
+		0,
+	}
+}
+
+/*
+sharedValues keeps the distinct values of a shared component, used by all the worlds with the registry.
+
+The values are allocated individually, so the pointers for them are stable, and released when
+no row references them anymore. The mutex guards the values between worlds used by different
+goroutines, the rows of every storage keep pointers to the values, so Get don't need it.
+The zero value is stored like the others, so writing to its pointer only changes the rows using it.
+*/
+type sharedValues[T comparable] struct {
+	mu    sync.Mutex
+	index map[T]*sharedValue[T]
+	ptrs  map[*T]*sharedValue[T]
+}
+
+// sharedValue is a distinct value, referenced by refs rows.
+// key is the value when it was interned, as the value can be changed by its pointer
+type sharedValue[T comparable] struct {
+	value T
+	key   T
+	refs  uint
+}
+
+func newSharedValues[T comparable]() *sharedValues[T] {
+	return &sharedValues[T]{
+		index: make(map[T]*sharedValue[T]),
+		ptrs:  make(map[*T]*sharedValue[T]),
+	}
+}
+
+// intern returns the shared value equal to *ptr, adding it if it's not already stored, with refs more references.
+// ptr can point to one of the stored values, in which case it's returned directly
+func (v *sharedValues[T]) intern(ptr *T, refs uint) *sharedValue[T] {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	value, ok := v.ptrs[ptr]
+	if !ok {
+		// values changed by their pointers are not in the index anymore
+		value, ok = v.index[*ptr]
+		if !ok || value.value != *ptr {
+			value = &sharedValue[T]{value: *ptr, key: *ptr}
+			v.index[value.key] = value
+			v.ptrs[&value.value] = value
+		}
+	}
+	value.refs += refs
+	return value
+}
+
+// retain adds a reference to the values
+func (v *sharedValues[T]) retain(values []*sharedValue[T]) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	for _, value := range values {
+		value.refs++
+	}
+}
+
+// release removes a reference from the values, releasing the ones not referenced anymore
+func (v *sharedValues[T]) release(values []*sharedValue[T]) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	for _, value := range values {
+		value.refs--
+		if value.refs > 0 {
+			continue
+		}
+		if v.index[value.key] == value {
+			delete(v.index, value.key)
+		}
+		delete(v.ptrs, &value.value)
+	}
+}
+
+// len returns the number of distinct values stored
+func (v *sharedValues[T]) len() int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return len(v.ptrs)
+}
+
+// sharedStorage is the Storage for a shared component in one archetype.
+// Every row below len(rows) keeps the pointer to its value in sharedValues, and the rows
+// added by Expand start with the zero value
+type sharedStorage[T comparable] struct {
+	values    *sharedValues[T]
+	rows      []*sharedValue[T]
+	increment uint
+}
+
+func newSharedStorage[T comparable](values *sharedValues[T], initialLen, increment uint) Storage {
+	s := &sharedStorage[T]{values: values, increment: increment}
+	s.Expand(initialLen)
+	return s
+}
+
+func (s *sharedStorage[T]) Get(row uint) unsafe.Pointer {
+	return unsafe.Pointer(&s.rows[row].value)
+}
+
+func (s *sharedStorage[T]) Set(row uint, value interface{}) bool {
+	if row >= uint(len(s.rows)) {
+		return false
+	}
+	ptr, ok := value.(*T)
+	if ok {
+		s.Copy(row, unsafe.Pointer(ptr))
+	}
+	return ok
+}
+
+func (s *sharedStorage[T]) Copy(row uint, ptr unsafe.Pointer) {
+	value := s.values.intern((*T)(ptr), 1)
+	s.values.release(s.rows[row : row+1])
+	s.rows[row] = value
+}
+
+func (s *sharedStorage[T]) CopyRange(row uint, src Storage, from, count uint) {
+	if other, ok := src.(*sharedStorage[T]); ok && other.values == s.values {
+		s.values.retain(other.rows[from : from+count])
+		s.values.release(s.rows[row : row+count])
+		copy(s.rows[row:row+count], other.rows[from:from+count])
+		return
+	}
+	copyRange(s, row, src, from, count)
+}
+
+// fillZero sets the rows to the zero value, without releasing the old ones
+func (s *sharedStorage[T]) fillZero(rows []*sharedValue[T]) {
+	if len(rows) == 0 {
+		return
+	}
+	var zero T
+	value := s.values.intern(&zero, uint(len(rows)))
+	for i := range rows {
+		rows[i] = value
+	}
+}
+
+// MoveLast drops the last row when it's the last one stored, so Expand gives it the zero value again
+func (s *sharedStorage[T]) MoveLast(row, last uint) {
+	if row != last {
+		s.values.release(s.rows[row : row+1])
+		s.rows[row] = s.rows[last]
+	} else {
+		s.values.release(s.rows[last : last+1])
+	}
+	if last+1 == uint(len(s.rows)) {
+		s.rows[last] = nil
+		s.rows = s.rows[:last]
+	} else {
+		s.fillZero(s.rows[last : last+1])
+	}
+}
+
+func (s *sharedStorage[T]) Shrink(to uint) {
+	if to < uint(cap(s.rows)) {
+		if to < uint(len(s.rows)) {
+			s.values.release(s.rows[to:])
+			s.rows = s.rows[:to]
+		}
+		rows := make([]*sharedValue[T], len(s.rows), to)
+		copy(rows, s.rows)
+		s.rows = rows
+	}
+}
+
+func (s *sharedStorage[T]) Expand(to uint) {
+	size := uint(len(s.rows))
+	if to <= size {
+		return
+	}
+	if to > uint(cap(s.rows)) {
+		rows := make([]*sharedValue[T], size, growCapacity(uint(cap(s.rows)), to, s.increment))
+		copy(rows, s.rows)
+		s.rows = rows
+	}
+	s.rows = s.rows[:to]
+	s.fillZero(s.rows[size:])
+}
+
+func (s *sharedStorage[T]) Reset() {
+	s.values.release(s.rows)
+	s.rows = make([]*sharedValue[T], 0)
+}
+
+func (s *sharedStorage[T]) Stats() StorageStats {
+	return StorageStats{
+		typeFor[T](),
+		uint(unsafe.Sizeof(uintptr(0))),
+		uint(cap(s.rows)),
+		scanBytes(typeFor[*sharedValue[T]](), uint(cap(s.rows))),
 	}
 }
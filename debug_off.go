@@ -0,0 +1,6 @@
+//go:build !ecsdebug
+
+package ecs
+
+// debugChecks enables the detection of stale archetypes and queries, see the ecsdebug build tag
+const debugChecks = false
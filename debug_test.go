@@ -0,0 +1,88 @@
+//go:build ecsdebug
+
+package ecs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDebugStaleAccess(t *testing.T) {
+	const (
+		PositionCompID ComponentID = iota
+	)
+	type Position struct{ x, y float32 }
+
+	factory := NewComponentFactory()
+	factory.Register(NewComponentRegistry[Position](PositionCompID))
+	graph := NewArchetypeGraph(factory)
+
+	graph.Add(1, PositionCompID)
+	graph.Add(2, PositionCompID)
+
+	query := graph.Query(MakeComponentMask(PositionCompID))
+	assert.True(t, query.Next(), "expected query to find entities")
+	assert.NotPanics(t, func() { query.Component(PositionCompID) }, "query should be valid before structural changes")
+
+	graph.Rem(2)
+	assert.Panics(t, func() { query.Component(PositionCompID) }, "query should panic after structural changes")
+	assert.Panics(t, func() { query.Next() }, "query should panic after structural changes")
+
+	query.Restart()
+	assert.NotPanics(t, func() { query.Next() }, "Restart should revalidate the query")
+
+	arch, row := graph.Get(1)
+	assert.NotPanics(t, func() { arch.Component(PositionCompID, row) }, "archetype should be valid before the graph grows")
+	assert.Panics(t, func() { arch.Component(PositionCompID, row+1) }, "archetype should panic for invalid rows")
+
+	// force the archetypes to move in memory creating more archetypes than the initial capacity
+	for i := 1; i < 16; i++ {
+		factory.Register(NewComponentRegistry[Position](ComponentID(i)))
+	}
+	entity := EntityID(10)
+	for i := ComponentID(0); i < 16; i++ {
+		for j := i + 1; j < 16; j++ {
+			for k := j + 1; k < 16; k++ {
+				graph.Add(entity, i, j, k)
+				entity++
+			}
+		}
+	}
+	assert.Panics(t, func() { arch.Component(PositionCompID, row) }, "stale archetypes should panic")
+
+	arch, row = graph.Get(1)
+	assert.NotPanics(t, func() { arch.Component(PositionCompID, row) }, "Get should return a valid archetype")
+}
+
+func TestDebugQueryScope(t *testing.T) {
+	const (
+		PositionCompID ComponentID = iota
+		VelocityCompID
+		TagCompID
+	)
+	type Position struct{ x, y float32 }
+	type Velocity struct{ x, y float32 }
+
+	factory := NewComponentFactory()
+	factory.Register(NewComponentRegistry[Position](PositionCompID))
+	factory.Register(NewComponentRegistry[Velocity](VelocityCompID))
+	factory.Register(NewComponentRegistry[struct{}](TagCompID))
+	graph := NewArchetypeGraph(factory)
+
+	graph.Add(1, PositionCompID)
+	graph.Add(2, PositionCompID)
+	graph.Add(3, VelocityCompID)
+	graph.Add(4, VelocityCompID)
+
+	query := graph.Query(MakeComponentMask(PositionCompID))
+	assert.True(t, query.Next(), "expected query to find entities")
+
+	graph.Rem(3)
+	graph.Add(5, VelocityCompID, TagCompID)
+	assert.NotPanics(t, func() { query.Component(PositionCompID) }, "changes in other archetypes should not invalidate the query")
+	assert.True(t, query.Next(), "expected query to find the second entity")
+
+	graph.AddComponent(query.Entity(), TagCompID)
+	assert.Panics(t, func() { query.Component(PositionCompID) }, "moving entities of the query archetypes should invalidate the query")
+}
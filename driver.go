@@ -0,0 +1,211 @@
+package ecs
+
+import (
+	"fmt"
+	"time"
+)
+
+// Clock returns the time elapsed since an arbitrary point, used by the Driver to measure the frames
+type Clock interface {
+	Now() time.Duration
+}
+
+type systemClock struct {
+	start time.Time
+}
+
+// NewSystemClock returns a Clock using the monotonic time of the system
+func NewSystemClock() Clock {
+	return systemClock{time.Now()}
+}
+
+func (c systemClock) Now() time.Duration {
+	return time.Since(c.start)
+}
+
+// FakeClock is a Clock advanced manually, for deterministic tests and replays
+type FakeClock struct {
+	now time.Duration
+}
+
+// NewFakeClock returns a FakeClock starting at 0
+func NewFakeClock() *FakeClock {
+	return &FakeClock{}
+}
+
+// Now returns the time advanced so far
+func (c *FakeClock) Now() time.Duration {
+	return c.now
+}
+
+// Advance moves the clock forward by d
+func (c *FakeClock) Advance(d time.Duration) {
+	c.now += d
+}
+
+// DefaultMaxSteps is the catch-up limit used by the fixed step groups with MaxSteps == 0
+const DefaultMaxSteps = 5
+
+/*
+Group is a set of systems updated by the Driver at its own rate.
+
+With Step == 0, Run is called every frame with the frame time.
+With Fixed, Run is called with dt == Step as many times as the accumulated time allows, up to
+MaxSteps per frame. The time left in the accumulator gives the interpolation alpha, see Driver.Alpha.
+Otherwise, Run is called at most once per frame after Step passed, with the time since the last call.
+*/
+type Group struct {
+	// Name identifies the group in Driver.Alpha, it must be unique in the Driver
+	Name string
+	// Step is the time between the updates, like time.Second / 60 for 60 Hz
+	Step time.Duration
+	// Fixed runs the group with a fixed timestep, catching up when the frames are slower than Step
+	Fixed bool
+	// MaxSteps limits the fixed steps run in a frame, DefaultMaxSteps when 0.
+	// The time beyond the limit is dropped, so a slow frame doesn't make the next ones slower
+	MaxSteps int
+	// Run updates the group, usually calling Scheduler.Run
+	Run func(dt time.Duration)
+}
+
+type driverGroup struct {
+	Group
+	accumulator time.Duration
+	steps       uint64
+	dropped     time.Duration
+}
+
+/*
+Driver runs groups of systems at different rates, like fixed step physics at 60 Hz,
+AI at 10 Hz and rendering every frame:
+
+	driver := ecs.NewDriver(nil)
+	driver.Add(ecs.Group{Name: "physics", Step: time.Second / 60, Fixed: true, Run: physics})
+	driver.Add(ecs.Group{Name: "ai", Step: time.Second / 10, Run: ai})
+	driver.Add(ecs.Group{Name: "render", Run: func(dt time.Duration) {
+		render(driver.Alpha("physics"))
+	}})
+
+	for running {
+		driver.Frame()
+	}
+
+The groups run in the order they were added. With the same Clock readings, the groups run
+the same number of times with the same dt, so the simulation is deterministic.
+*/
+type Driver struct {
+	clock  Clock
+	groups []*driverGroup
+	names  map[string]*driverGroup
+	last   time.Duration
+	frames uint64
+}
+
+// NewDriver returns a Driver measuring the time with the clock, or with NewSystemClock when nil
+func NewDriver(clock Clock) *Driver {
+	if clock == nil {
+		clock = NewSystemClock()
+	}
+	return &Driver{
+		clock,
+		nil,
+		make(map[string]*driverGroup),
+		0,
+		0,
+	}
+}
+
+// Add appends the group to the driver. Panics if the name is in use, Run is nil or Fixed is set without Step
+func (d *Driver) Add(group Group) *Driver {
+	if group.Run == nil {
+		panic("Driver: group without Run function")
+	}
+	if group.Fixed && group.Step <= 0 {
+		panic("Driver: fixed group without Step")
+	}
+	if _, ok := d.names[group.Name]; ok {
+		panic(fmt.Sprintf("Driver: group %q already added", group.Name))
+	}
+	if group.MaxSteps <= 0 {
+		group.MaxSteps = DefaultMaxSteps
+	}
+
+	g := &driverGroup{Group: group}
+	d.groups = append(d.groups, g)
+	d.names[group.Name] = g
+	return d
+}
+
+// Frame reads the clock and runs the groups due since the last frame.
+// The first frame only starts the measure, running the groups with dt == 0
+func (d *Driver) Frame() {
+	now := d.clock.Now()
+	var dt time.Duration
+	if d.frames > 0 {
+		dt = now - d.last
+	}
+	d.last = now
+	d.frames++
+
+	for _, g := range d.groups {
+		switch {
+		case g.Step == 0:
+			g.steps++
+			g.Run(dt)
+
+		case g.Fixed:
+			g.accumulator += dt
+			for i := 0; i < g.MaxSteps && g.accumulator >= g.Step; i++ {
+				g.accumulator -= g.Step
+				g.steps++
+				g.Run(g.Step)
+			}
+			if g.accumulator >= g.Step {
+				// catch-up limit reached, keep the fraction of the step for the interpolation
+				dropped := g.accumulator - g.accumulator%g.Step
+				g.dropped += dropped
+				g.accumulator -= dropped
+			}
+
+		default:
+			g.accumulator += dt
+			if g.accumulator >= g.Step {
+				elapsed := g.accumulator
+				g.accumulator = 0
+				g.steps++
+				g.Run(elapsed)
+			}
+		}
+	}
+}
+
+// Alpha returns the interpolation factor, in [0, 1), between the last two fixed steps of the group,
+// used to render the state between the previous and the current step. Returns 0 for unknown groups.
+func (d *Driver) Alpha(name string) float64 {
+	g, ok := d.names[name]
+	if !ok || g.Step == 0 {
+		return 0
+	}
+	return float64(g.accumulator) / float64(g.Step)
+}
+
+// Steps returns how many times the group run, or 0 for unknown groups
+func (d *Driver) Steps(name string) uint64 {
+	if g, ok := d.names[name]; ok {
+		return g.steps
+	}
+	return 0
+}
+
+// Dropped returns the time dropped by the catch-up limit of the group, or 0 for unknown groups
+func (d *Driver) Dropped(name string) time.Duration {
+	if g, ok := d.names[name]; ok {
+		return g.dropped
+	}
+	return 0
+}
+
+// Frames returns the number of frames run
+func (d *Driver) Frames() uint64 {
+	return d.frames
+}
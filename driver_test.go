@@ -0,0 +1,112 @@
+package ecs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDriver(t *testing.T) {
+	const (
+		physicsStep = 10 * time.Millisecond
+		aiStep      = 50 * time.Millisecond
+	)
+
+	type call struct {
+		group string
+		dt    time.Duration
+	}
+	var calls []call
+	record := func(group string) func(time.Duration) {
+		return func(dt time.Duration) {
+			calls = append(calls, call{group, dt})
+		}
+	}
+	count := func(group string) (n int, total time.Duration) {
+		for _, c := range calls {
+			if c.group == group {
+				n++
+				total += c.dt
+			}
+		}
+		return n, total
+	}
+
+	clock := NewFakeClock()
+	driver := NewDriver(clock)
+	driver.Add(Group{Name: "physics", Step: physicsStep, Fixed: true, MaxSteps: 3, Run: record("physics")})
+	driver.Add(Group{Name: "ai", Step: aiStep, Run: record("ai")})
+	driver.Add(Group{Name: "render", Run: record("render")})
+
+	driver.Frame()
+	assert.Equal(t, []call{{"render", 0}}, calls, "expected the first frame to only start the measure")
+
+	calls = nil
+	clock.Advance(25 * time.Millisecond)
+	driver.Frame()
+	assert.Equal(t, []call{{"physics", physicsStep}, {"physics", physicsStep}, {"render", 25 * time.Millisecond}}, calls,
+		"expected fixed steps to run with Step and the groups in order")
+	assert.InDelta(t, 0.5, driver.Alpha("physics"), 1e-9, "expected alpha from the time left in the accumulator")
+
+	calls = nil
+	clock.Advance(30 * time.Millisecond)
+	driver.Frame()
+	n, _ := count("physics")
+	assert.Equal(t, 3, n, "expected the accumulated time to be used by the fixed steps")
+	assert.InDelta(t, 0.5, driver.Alpha("physics"), 1e-9, "expected alpha from the time left in the accumulator")
+	n, total := count("ai")
+	assert.Equal(t, 1, n, "expected variable groups to run after Step")
+	assert.Equal(t, 55*time.Millisecond, total, "expected variable groups to receive the time since the last run")
+
+	calls = nil
+	clock.Advance(time.Second)
+	driver.Frame()
+	n, _ = count("physics")
+	assert.Equal(t, 3, n, "expected MaxSteps to limit the catch-up")
+	assert.Equal(t, 970*time.Millisecond, driver.Dropped("physics"), "expected the time beyond MaxSteps to be dropped")
+	assert.InDelta(t, 0.5, driver.Alpha("physics"), 1e-9, "expected the fraction of the step kept after the catch-up limit")
+
+	calls = nil
+	clock.Advance(5 * time.Millisecond)
+	driver.Frame()
+	n, _ = count("physics")
+	assert.Equal(t, 1, n, "expected the next frame to run normally after the catch-up limit")
+	assert.InDelta(t, 0, driver.Alpha("physics"), 1e-9, "expected alpha from the time left in the accumulator")
+	n, _ = count("ai")
+	assert.Equal(t, 0, n, "expected variable groups to wait for Step")
+
+	assert.EqualValues(t, 5, driver.Frames(), "expected the frames to be counted")
+	assert.EqualValues(t, 5, driver.Steps("render"), "expected groups without Step to run every frame")
+	assert.EqualValues(t, 9, driver.Steps("physics"), "expected the steps to be counted")
+	assert.Zero(t, driver.Alpha("unknown"), "expected 0 for unknown groups")
+
+	assert.Panics(t, func() {
+		driver.Add(Group{Name: "render", Run: record("render")})
+	}, "expected duplicated names to panic")
+	assert.Panics(t, func() {
+		driver.Add(Group{Name: "fixed", Fixed: true, Run: record("fixed")})
+	}, "expected fixed groups without Step to panic")
+	assert.Panics(t, func() {
+		driver.Add(Group{Name: "empty"})
+	}, "expected groups without Run to panic")
+
+	replay := func() []call {
+		calls = nil
+		clock := NewFakeClock()
+		driver := NewDriver(clock)
+		driver.Add(Group{Name: "physics", Step: time.Second / 60, Fixed: true, Run: record("physics")})
+		driver.Add(Group{Name: "ai", Step: time.Second / 10, Run: record("ai")})
+		for _, frame := range []time.Duration{0, 16, 17, 33, 8, 120, 16, 16, 250, 1} {
+			clock.Advance(frame * time.Millisecond)
+			driver.Frame()
+		}
+		return calls
+	}
+	assert.Equal(t, replay(), replay(), "expected the same clock readings to run the same steps")
+
+	system := NewDriver(nil)
+	system.Add(Group{Name: "render", Run: func(time.Duration) {}})
+	system.Frame()
+	assert.EqualValues(t, 1, system.Frames(), "expected the system clock by default")
+}
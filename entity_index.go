@@ -0,0 +1,76 @@
+package ecs
+
+const (
+	entityIndexPageBits = 12 // 4096 entities per page
+	entityIndexPageSize = 1 << entityIndexPageBits
+	entityIndexPageMask = entityIndexPageSize - 1
+)
+
+// entityLocation keeps the archetype and row of the entity.
+// The entity is stored to check the generation, as the slot is shared by all generations of the ID
+type entityLocation struct {
+	entity    EntityID
+	archetype uint32 // archetype index + 1, zero for unused slots
+	row       uint32
+}
+
+/*
+entityIndex maps the entities to their location in the archetype graph, indexed by EntityID.ID().
+
+The locations are stored in pages allocated on first use, so the index never copies the
+existing locations when it grows, and IDs far apart don't allocate the pages between them.
+*/
+type entityIndex struct {
+	pages [][]entityLocation
+}
+
+// get returns the location of the entity, or false if the entity, with the same generation, is not in the index
+func (e *entityIndex) get(entity EntityID) (archetypeEntityIndex, bool) {
+	id := entity.ID()
+	page := id >> entityIndexPageBits
+	if page >= uint64(len(e.pages)) || e.pages[page] == nil {
+		return archetypeEntityIndex{}, false
+	}
+	loc := &e.pages[page][id&entityIndexPageMask]
+	if loc.archetype == 0 || loc.entity != entity {
+		return archetypeEntityIndex{}, false
+	}
+	return archetypeEntityIndex{int(loc.archetype - 1), loc.row}, true
+}
+
+// set stores the location of the entity, replacing any other generation of the same ID
+func (e *entityIndex) set(entity EntityID, index archetypeEntityIndex) {
+	id := entity.ID()
+	page := id >> entityIndexPageBits
+	if page >= uint64(len(e.pages)) {
+		pages := make([][]entityLocation, page+1)
+		copy(pages, e.pages)
+		e.pages = pages
+	}
+	if e.pages[page] == nil {
+		e.pages[page] = make([]entityLocation, entityIndexPageSize)
+	}
+	e.pages[page][id&entityIndexPageMask] = entityLocation{entity, uint32(index.archetype + 1), index.row}
+}
+
+// setRow changes the row of the entity, keeping the archetype
+func (e *entityIndex) setRow(entity EntityID, row uint32) {
+	id := entity.ID()
+	loc := &e.pages[id>>entityIndexPageBits][id&entityIndexPageMask]
+	if loc.entity == entity {
+		loc.row = row
+	}
+}
+
+// rem removes the entity from the index
+func (e *entityIndex) rem(entity EntityID) {
+	id := entity.ID()
+	page := id >> entityIndexPageBits
+	if page >= uint64(len(e.pages)) || e.pages[page] == nil {
+		return
+	}
+	loc := &e.pages[page][id&entityIndexPageMask]
+	if loc.entity == entity {
+		*loc = entityLocation{}
+	}
+}
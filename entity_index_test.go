@@ -0,0 +1,60 @@
+package ecs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEntityIndex(t *testing.T) {
+	var index entityIndex
+
+	e := MakeEntity(10, 0)
+	_, ok := index.get(e)
+	assert.False(t, ok, "expected get to fail for empty index")
+
+	index.set(e, archetypeEntityIndex{0, 5})
+	loc, ok := index.get(e)
+	assert.True(t, ok, "expected get to find the entity")
+	assert.Equal(t, archetypeEntityIndex{0, 5}, loc, "expected get to return the location")
+	assert.Len(t, index.pages, 1, "expected one page for the first IDs")
+
+	next := MakeEntity(10, 1)
+	_, ok = index.get(next)
+	assert.False(t, ok, "expected get to fail for other generations")
+	index.rem(next)
+	_, ok = index.get(e)
+	assert.True(t, ok, "expected rem to ignore other generations")
+
+	index.setRow(e, 7)
+	loc, _ = index.get(e)
+	assert.Equal(t, archetypeEntityIndex{0, 7}, loc, "expected setRow to change the row")
+	index.setRow(next, 9)
+	loc, _ = index.get(e)
+	assert.Equal(t, archetypeEntityIndex{0, 7}, loc, "expected setRow to ignore other generations")
+
+	index.rem(e)
+	_, ok = index.get(e)
+	assert.False(t, ok, "expected rem to remove the entity")
+
+	far := MakeEntity(entityIndexPageSize*4+1, 0)
+	index.set(far, archetypeEntityIndex{3, 1})
+	loc, ok = index.get(far)
+	assert.True(t, ok && loc == archetypeEntityIndex{3, 1}, "expected get to find entities in other pages")
+	assert.Len(t, index.pages, 5, "expected pages up to the entity ID")
+	assert.Nil(t, index.pages[2], "expected pages between the IDs to not be allocated")
+	_, ok = index.get(MakeEntity(entityIndexPageSize*2, 0))
+	assert.False(t, ok, "expected get to fail for pages not allocated")
+	_, ok = index.get(MakeEntity(entityIndexPageSize*10, 0))
+	assert.False(t, ok, "expected get to fail for IDs out of the index")
+	index.rem(MakeEntity(entityIndexPageSize*10, 0))
+
+	w := NewWorld(0)
+	w.Register(NewComponentRegistry[struct{ value int }](0))
+	stale := w.NewEntity(0)
+	w.RemEntity(stale)
+	reused := w.NewEntity(0)
+	assert.Equal(t, stale.ID(), reused.ID(), "expected the ID to be reused")
+	assert.NotNil(t, w.Component(reused, 0), "expected the new generation to have the component")
+	assert.True(t, w.Component(stale, 0) == nil, "expected stale entities to not access the new generation")
+}
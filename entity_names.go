@@ -0,0 +1,197 @@
+package ecs
+
+import "strings"
+
+// EntityPathSeparator separates the names of the entities in a hierarchical path, like "level1/door3"
+const EntityPathSeparator = "/"
+
+// entityNode holds the optional name and parent of an entity
+type entityNode struct {
+	name   string
+	parent EntityID
+}
+
+// entityNameKey identifies a name in the scope of its parent. Root entities have parent 0
+type entityNameKey struct {
+	parent EntityID
+	name   string
+}
+
+/*
+entityNames is the index used by the World to keep the names and parent relations of the entities.
+
+Names are unique between siblings, so the same name can be reused by entities with different parents
+and the path "level1/door3" is resolved by walking the names from the root to the last entity.
+*/
+type entityNames struct {
+	nodes    map[EntityID]entityNode
+	index    map[entityNameKey]EntityID
+	children map[EntityID][]EntityID
+}
+
+func newEntityNames() *entityNames {
+	return &entityNames{
+		nodes:    make(map[EntityID]entityNode),
+		index:    make(map[entityNameKey]EntityID),
+		children: make(map[EntityID][]EntityID),
+	}
+}
+
+// setName changes the name of the entity. An empty name releases the current one.
+// Returns false if the name is invalid or already used by a sibling
+func (n *entityNames) setName(entity EntityID, name string) bool {
+	if strings.Contains(name, EntityPathSeparator) {
+		return false
+	}
+
+	node := n.nodes[entity]
+	if node.name == name {
+		return true
+	}
+
+	if name != "" {
+		if _, taken := n.index[entityNameKey{node.parent, name}]; taken {
+			return false
+		}
+		n.index[entityNameKey{node.parent, name}] = entity
+	}
+	if node.name != "" {
+		delete(n.index, entityNameKey{node.parent, node.name})
+	}
+
+	node.name = name
+	n.update(entity, node)
+	return true
+}
+
+// setParent changes the parent of the entity. A parent 0 detaches the entity from its parent.
+// Returns false if the entity name is already used in the new parent or the relation makes a cycle
+func (n *entityNames) setParent(entity, parent EntityID) bool {
+	node := n.nodes[entity]
+	if node.parent == parent {
+		return true
+	}
+
+	for p := parent; p != 0; p = n.nodes[p].parent {
+		if p == entity {
+			return false
+		}
+	}
+
+	if node.name != "" {
+		if _, taken := n.index[entityNameKey{parent, node.name}]; taken {
+			return false
+		}
+		delete(n.index, entityNameKey{node.parent, node.name})
+		n.index[entityNameKey{parent, node.name}] = entity
+	}
+
+	n.unlinkChild(node.parent, entity)
+	if parent != 0 {
+		n.children[parent] = append(n.children[parent], entity)
+	}
+
+	node.parent = parent
+	n.update(entity, node)
+	return true
+}
+
+// rem releases the name of the entity and detaches its children.
+// Children whose name is already used by another root entity lose their name.
+func (n *entityNames) rem(entity EntityID) {
+	// entities without name and parent have no node, but can still have children
+	for _, child := range n.children[entity] {
+		childNode := n.nodes[child]
+		if childNode.name != "" {
+			delete(n.index, entityNameKey{entity, childNode.name})
+			if _, taken := n.index[entityNameKey{0, childNode.name}]; taken {
+				childNode.name = ""
+			} else {
+				n.index[entityNameKey{0, childNode.name}] = child
+			}
+		}
+		childNode.parent = 0
+		n.update(child, childNode)
+	}
+	delete(n.children, entity)
+
+	node, ok := n.nodes[entity]
+	if !ok {
+		return
+	}
+	if node.name != "" {
+		delete(n.index, entityNameKey{node.parent, node.name})
+	}
+	n.unlinkChild(node.parent, entity)
+	delete(n.nodes, entity)
+}
+
+func (n *entityNames) name(entity EntityID) string {
+	return n.nodes[entity].name
+}
+
+func (n *entityNames) parent(entity EntityID) EntityID {
+	return n.nodes[entity].parent
+}
+
+// path returns the names from the root to the entity, joined by EntityPathSeparator
+func (n *entityNames) path(entity EntityID) string {
+	node, ok := n.nodes[entity]
+	if !ok || node.name == "" {
+		return ""
+	}
+	if node.parent == 0 {
+		return node.name
+	}
+	parentPath := n.path(node.parent)
+	if parentPath == "" {
+		return ""
+	}
+	return parentPath + EntityPathSeparator + node.name
+}
+
+// lookup resolves the path, returning the entity with the last name in it
+func (n *entityNames) lookup(path string) (EntityID, bool) {
+	if path == "" {
+		return 0, false
+	}
+
+	entity := EntityID(0)
+	for _, name := range strings.Split(path, EntityPathSeparator) {
+		next, ok := n.index[entityNameKey{entity, name}]
+		if !ok {
+			return 0, false
+		}
+		entity = next
+	}
+	return entity, true
+}
+
+// update stores the node, discarding it when it has no name and parent
+func (n *entityNames) update(entity EntityID, node entityNode) {
+	if node.name == "" && node.parent == 0 {
+		delete(n.nodes, entity)
+		return
+	}
+	n.nodes[entity] = node
+}
+
+func (n *entityNames) unlinkChild(parent, child EntityID) {
+	if parent == 0 {
+		return
+	}
+	siblings := n.children[parent]
+	for i, sibling := range siblings {
+		if sibling == child {
+			last := len(siblings) - 1
+			siblings[i] = siblings[last]
+			siblings = siblings[:last]
+			break
+		}
+	}
+	if len(siblings) == 0 {
+		delete(n.children, parent)
+	} else {
+		n.children[parent] = siblings
+	}
+}
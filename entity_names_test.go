@@ -0,0 +1,79 @@
+package ecs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEntityNames(t *testing.T) {
+	world := NewWorld(10)
+
+	level := world.NewEntity()
+	door := world.NewEntity()
+	player := world.NewEntity()
+
+	assert.True(t, world.SetName(player, "player"), "expected SetName to accept a valid name")
+	assert.True(t, world.SetName(level, "level1"), "expected SetName to accept a valid name")
+	assert.True(t, world.SetName(door, "door3"), "expected SetName to accept a valid name")
+	assert.False(t, world.SetName(door, "level1"), "expected SetName to fail for names in use")
+	assert.False(t, world.SetName(door, "level1/door3"), "expected SetName to fail for names with separator")
+	assert.Equal(t, "door3", world.Name(door), "expected Name to return the entity name")
+
+	e, ok := world.Lookup("player")
+	assert.True(t, ok && e == player, "expected Lookup to find the entity by name (want %x, got %x)", player, e)
+
+	assert.True(t, world.SetParent(door, level), "expected SetParent to accept alive entities")
+	assert.Equal(t, level, world.Parent(door), "expected Parent to return the entity parent")
+	assert.False(t, world.SetParent(level, door), "expected SetParent to fail for cycles")
+	assert.Equal(t, "level1/door3", world.Path(door), "expected Path to return the full path")
+
+	e, ok = world.Lookup("level1/door3")
+	assert.True(t, ok && e == door, "expected Lookup to resolve paths (want %x, got %x)", door, e)
+	_, ok = world.Lookup("door3")
+	assert.False(t, ok, "expected children not to be found in the root")
+
+	window := world.NewEntity()
+	assert.True(t, world.SetParent(window, level), "expected SetParent to accept unnamed entities")
+	assert.Empty(t, world.Path(window), "expected Path to be empty for unnamed entities")
+	assert.True(t, world.SetParent(window, 0), "expected SetParent to detach entities from the parent")
+	assert.True(t, world.SetParent(window, door), "expected SetParent to accept alive entities")
+	assert.True(t, world.SetName(window, "window"), "expected SetName to accept a valid name")
+	assert.True(t, world.SetName(window, "window"), "expected SetName to accept the current name")
+	assert.Equal(t, "level1/door3/window", world.Path(window), "expected Path to return the full path")
+
+	other := world.NewEntity()
+	assert.True(t, world.SetName(other, "door3"), "expected names to be unique only between siblings")
+	assert.False(t, world.SetParent(other, level), "expected SetParent to fail for names in use by siblings")
+
+	world.RemEntity(player)
+	_, ok = world.Lookup("player")
+	assert.False(t, ok, "expected removed entities to release their names")
+	assert.False(t, world.SetName(player, "player"), "expected SetName to fail for removed entities")
+	assert.False(t, world.SetParent(door, player), "expected SetParent to fail for removed parents")
+	world.RemEntity(window)
+	assert.Empty(t, world.Path(window), "expected removed entities to release their path")
+
+	world.RemEntity(level)
+	_, ok = world.Lookup("level1/door3")
+	assert.False(t, ok, "expected Lookup to fail for paths with removed entities")
+	assert.Zero(t, world.Parent(door), "expected children of removed entities to be detached")
+	assert.Empty(t, world.Name(door), "expected detached children to lose names in use by root entities")
+
+	assert.True(t, world.SetName(other, ""), "expected empty names to release the name")
+	assert.True(t, world.SetName(door, "door3"), "expected released names to be available")
+	e, ok = world.Lookup("door3")
+	assert.True(t, ok && e == door, "expected Lookup to find the detached entity (want %x, got %x)", door, e)
+	_, ok = world.Lookup("")
+	assert.False(t, ok, "expected Lookup to fail for empty paths")
+
+	group := world.NewEntity()
+	lamp := world.NewEntity()
+	assert.True(t, world.SetName(lamp, "lamp"), "expected SetName to accept a valid name")
+	assert.True(t, world.SetParent(lamp, group), "expected SetParent to accept unnamed parents")
+	world.RemEntity(group)
+	assert.Zero(t, world.Parent(lamp), "expected children of removed unnamed entities to be detached")
+	assert.Equal(t, "lamp", world.Path(lamp), "expected Path to resolve the detached children")
+	e, ok = world.Lookup("lamp")
+	assert.True(t, ok && e == lamp, "expected Lookup to find the detached children (want %x, got %x)", lamp, e)
+}
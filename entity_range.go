@@ -0,0 +1,147 @@
+package ecs
+
+// EntityRange is an inclusive range of entity IDs, without the generation and flags
+type EntityRange struct {
+	First, Last uint64
+}
+
+// Contains returns true if the entity ID is in the range
+func (r EntityRange) Contains(entity EntityID) bool {
+	return entity.ID() >= r.First && entity.ID() <= r.Last
+}
+
+// EntityRangeHighBit splits the IDs in two ranges by the highest bit of the identifier
+const EntityRangeHighBit = uint64(1) << (EntityIdentifierBitCount - 1)
+
+var (
+	// EntityRangeLow is the range of IDs without EntityRangeHighBit, like the IDs created by a server
+	EntityRangeLow = EntityRange{1, EntityRangeHighBit - 1}
+	// EntityRangeHigh is the range of IDs with EntityRangeHighBit, like the IDs predicted by a client
+	EntityRangeHigh = EntityRange{EntityRangeHighBit, EntityIdentifierMask}
+)
+
+/*
+NewEntityPoolRange returns an EntityPool that only creates IDs in the range.
+Panics if the range is empty, includes the ID 0 or is beyond EntityIdentifierMask
+*/
+func NewEntityPoolRange(initialCap uint, r EntityRange) EntityPool {
+	return newEntityPool(initialCap, r)
+}
+
+/*
+NewPartitionedEntityPool returns an EntityPool with disjoint ranges of IDs, in ascending order.
+New and Reserve create IDs in the ranges[alloc], and the IDs of the other ranges are only added with Insert.
+
+For client and server play, the client can predict entities in the high range and insert the ones
+received from the server in the low range, without collisions:
+
+	// server
+	pool := ecs.NewEntityPoolRange(0, ecs.EntityRangeLow)
+	// client
+	pool := ecs.NewPartitionedEntityPool(0, 1, ecs.EntityRangeLow, ecs.EntityRangeHigh)
+	pool.Insert(serverEntity)
+*/
+func NewPartitionedEntityPool(initialCap uint, alloc int, ranges ...EntityRange) EntityPool {
+	if alloc < 0 || alloc >= len(ranges) {
+		panic("EntityPool: invalid allocation range")
+	}
+	pools := make([]*entityPool, len(ranges))
+	for i, r := range ranges {
+		if i > 0 && r.First <= ranges[i-1].Last {
+			panic("EntityPool: entity ranges must be disjoint and in ascending order")
+		}
+		if i != alloc {
+			// only filled by Insert
+			pools[i] = newEntityPool(1, r)
+		} else {
+			pools[i] = newEntityPool(initialCap, r)
+		}
+	}
+	return &partitionedEntityPool{pools, ranges, pools[alloc]}
+}
+
+type partitionedEntityPool struct {
+	pools  []*entityPool
+	ranges []EntityRange
+	alloc  *entityPool
+}
+
+// pool returns the pool with the range of the entity or nil
+func (p *partitionedEntityPool) pool(entity EntityID) *entityPool {
+	for i, r := range p.ranges {
+		if r.Contains(entity) {
+			return p.pools[i]
+		}
+	}
+	return nil
+}
+
+func (p *partitionedEntityPool) New() EntityID {
+	return p.alloc.New()
+}
+
+func (p *partitionedEntityPool) Reserve() EntityID {
+	return p.alloc.Reserve()
+}
+
+func (p *partitionedEntityPool) Materialize(fn func(EntityID)) {
+	for _, pool := range p.pools {
+		pool.Materialize(fn)
+	}
+}
+
+func (p *partitionedEntityPool) Recycle(entity EntityID) bool {
+	if pool := p.pool(entity); pool != nil {
+		return pool.Recycle(entity)
+	}
+	return false
+}
+
+func (p *partitionedEntityPool) IsAlive(entity EntityID) bool {
+	if pool := p.pool(entity); pool != nil {
+		return pool.IsAlive(entity)
+	}
+	return false
+}
+
+func (p *partitionedEntityPool) Insert(entity EntityID) bool {
+	if pool := p.pool(entity); pool != nil {
+		return pool.Insert(entity)
+	}
+	return false
+}
+
+func (p *partitionedEntityPool) Stats() EntityPoolStats {
+	var stats EntityPoolStats
+	for _, pool := range p.pools {
+		s := pool.Stats()
+		stats.Alive += s.Alive
+		stats.Recycled += s.Recycled
+		stats.Reserved += s.Reserved
+		stats.Retired += s.Retired
+		stats.Cap += s.Cap
+	}
+	return stats
+}
+
+func (p *partitionedEntityPool) Len() uint {
+	return p.Stats().Alive
+}
+
+func (p *partitionedEntityPool) Each(fn func(EntityID)) {
+	for _, pool := range p.pools {
+		pool.Each(fn)
+	}
+}
+
+func (p *partitionedEntityPool) SetRecyclePolicy(policy RecyclePolicy) {
+	for _, pool := range p.pools {
+		pool.SetRecyclePolicy(policy)
+	}
+}
+
+func (p *partitionedEntityPool) Maintain() {
+	for _, pool := range p.pools {
+		pool.Maintain()
+	}
+}
@@ -1,31 +1,122 @@
 package ecs
 
+import (
+	"sort"
+	"sync/atomic"
+)
+
 /*
 EntityPool defines the interface for the entity ID manager.
 It uses an implicit linked list to keep track of all recycled IDs without an additional buffer.
 When a recycled ID is returned, it have its generation bits incremented to differentiate it from
-the older one.
+the older one. IDs recycled with the last generation are retired and never reused, otherwise the
+generation would wrap and old IDs would become alive again.
 
 # New returns a new EntityID
 
 Recycle puts the EntityID in the recycle list for reuse. If the entity is not alive, returns false and do nothing.
 
-IsAlive returns true if the EntityID is alive in the pool
+# IsAlive returns true if the EntityID is alive in the pool
+
+# Stats returns the number of entities alive, recycled, reserved, retired and the pool capacity
+
+Reserve returns an EntityID that will be valid after the next call to Materialize. It's the only
+method safe to call from multiple goroutines, as long as Materialize and Insert are not running.
+
+# Materialize makes the reserved IDs alive, calling fn for each one of them
+
+# Len returns the number of entities alive
+
+Each calls fn for every entity alive, in ID order. The pool must not be changed by fn
+
+Insert makes the EntityID alive with its generation, like the IDs received from a server.
+Returns false if the ID is out of the pool range, alive, retired or reserved, or if the generation
+is older than the one the pool would use for the ID, as the entity was already removed
+
+# SetRecyclePolicy changes the order and the delay for reusing the recycled IDs
+
+Maintain should be called once per frame when RecyclePolicy.QuarantineFrames is used
 */
 type EntityPool interface {
 	New() EntityID
 	Recycle(e EntityID) bool
 	IsAlive(e EntityID) bool
+	Stats() EntityPoolStats
+	Reserve() EntityID
+	Materialize(fn func(EntityID))
+	Len() uint
+	Each(fn func(EntityID))
+	Insert(e EntityID) bool
+	SetRecyclePolicy(policy RecyclePolicy)
+	Maintain()
+}
+
+/*
+RecyclePolicy controls when the recycled IDs are reused by EntityPool.New.
+
+By default the last ID recycled is the first reused, keeping the IDs compact, but also making
+stale references hit a recycled ID in the same frame. With FIFO the oldest ID recycled is reused
+first, and the quarantine keeps the IDs out of use for a minimum number of allocations or frames.
+New creates fresh IDs while the oldest recycled ID is in quarantine.
+*/
+type RecyclePolicy struct {
+	// FIFO reuses the oldest ID recycled instead of the last one
+	FIFO bool
+	// QuarantineAllocations is the number of calls to New before a recycled ID is reused. Implies FIFO
+	QuarantineAllocations uint
+	// QuarantineFrames is the number of calls to Maintain before a recycled ID is reused. Implies FIFO
+	QuarantineFrames uint
+}
+
+func (p RecyclePolicy) fifo() bool {
+	return p.FIFO || p.quarantine()
+}
+
+func (p RecyclePolicy) quarantine() bool {
+	return p.QuarantineAllocations > 0 || p.QuarantineFrames > 0
 }
 
 const (
 	EntityPoolInitialCapacity = 1024 * 10 // default initial buffer size
+	EntityMaxGeneration       = EntityGenerationMask >> EntityGenerationShift
+)
+
+const (
+	// retiredEntity is stored in the slots with exhausted generation. The flags are never set in the
+	// slots, so it can't be confused with the last recycled slot in the last generation
+	retiredEntity = EntityID(EntityFlagsMask | EntityGenerationMask)
+	// reservedEntity is stored in the slots reserved and not materialized yet
+	reservedEntity = EntityID(EntityFlagsMask)
 )
 
+/*
+entityPool stores the entity of the ID base+i in entities[i], the slot 0 is never used.
+Alive slots store their own ID, and the recycled ones store the ID of the next recycled slot.
+
+The IDs inserted after the next slot never used are kept in ahead, so the slots between them are
+not allocated. The slots are moved to entities when the pool grows up to them, and New and Reserve
+skip them.
+*/
 type entityPool struct {
 	entities  []EntityID
-	next      uint64
+	base      uint64
+	size      uint64 // number of IDs in the pool range
+	next      uint64 // ID of the first recycled slot
+	last      uint64 // ID of the last recycled slot
 	available uint64
+	fresh     uint64               // next slot never used, shared with Reserve
+	synced    uint64               // slots below synced are already materialized
+	gaps      uint64               // reserved slots below len(entities) not materialized yet
+	ahead     map[uint64]*EntityID // slots inserted beyond len(entities)
+	retired   uint64
+	policy    RecyclePolicy
+	stamps    []recycleStamp // when each ID of the recycle list leaves the quarantine, in order
+	allocs    uint64
+	frame     uint64
+}
+
+type recycleStamp struct {
+	allocs, frame uint64
 }
 
 /*
@@ -33,51 +124,277 @@ NewEntityPool returns a implementation of EntityPool with initialCap as capacity
 If the initialCap == 0, the initial capcity is set to EntityPoolInitialCapacity
 */
 func NewEntityPool(initialCap uint) EntityPool {
+	return newEntityPool(initialCap, EntityRange{1, EntityIdentifierMask})
+}
+
+func newEntityPool(initialCap uint, r EntityRange) *entityPool {
+	if r.First == 0 || r.First > r.Last || r.Last > EntityIdentifierMask {
+		panic("EntityPool: invalid entity range")
+	}
 	if initialCap == 0 {
 		initialCap = EntityPoolInitialCapacity
 	}
 	ep := &entityPool{
 		entities:  make([]EntityID, 1, initialCap+1),
+		base:      r.First - 1,
+		size:      r.Last - r.First + 1,
 		next:      0,
 		available: 0,
+		fresh:     1,
+		synced:    1,
 	}
 
 	return ep
 }
 
 func (e *entityPool) New() EntityID {
-	if e.available > 0 {
+	e.allocs++
+	if e.available > 0 && e.reusable() {
 		e.available--
-		index := e.next
-		entity := e.entities[index]
+		id := e.next
+		slot := e.slot(id - e.base)
+		entity := *slot
 		e.next = entity.ID()
-		e.entities[index] = entity.SetID(index)
-		return e.entities[index]
+		if e.next == 0 {
+			e.last = 0
+		}
+		if e.stamps != nil {
+			e.stamps = e.stamps[1:]
+		}
+		*slot = entity.SetID(id)
+		return *slot
 	}
 
-	entity := MakeEntity(uint64(len(e.entities)), 0)
+	index := e.newSlot()
+	e.grow(index)
+	entity := MakeEntity(e.base+index, 0)
 	e.entities = append(e.entities, entity)
 	return entity
 }
 
+func (e *entityPool) Reserve() EntityID {
+	return MakeEntity(e.base+e.newSlot(), 0)
+}
+
+// newSlot returns the next slot never used, skipping the inserted ones
+func (e *entityPool) newSlot() uint64 {
+	for {
+		index := atomic.AddUint64(&e.fresh, 1) - 1
+		if index > e.size {
+			panic("EntityPool: entity range exhausted")
+		}
+		if _, inserted := e.ahead[index]; !inserted {
+			return index
+		}
+	}
+}
+
+// grow appends the slots until index, moving the inserted ones from ahead. The others are reserved
+func (e *entityPool) grow(index uint64) {
+	for i := uint64(len(e.entities)); i < index; i++ {
+		if slot, inserted := e.ahead[i]; inserted {
+			e.entities = append(e.entities, *slot)
+			delete(e.ahead, i)
+		} else {
+			e.entities = append(e.entities, reservedEntity)
+			e.gaps++
+		}
+	}
+}
+
+// slot returns the slot of the index, or nil if it's reserved beyond len(entities) or never used
+func (e *entityPool) slot(index uint64) *EntityID {
+	if index < uint64(len(e.entities)) {
+		return &e.entities[index]
+	}
+	return e.ahead[index]
+}
+
+func (e *entityPool) Materialize(fn func(EntityID)) {
+	fresh := atomic.LoadUint64(&e.fresh)
+	for index := e.synced; index < fresh; index++ {
+		entity := MakeEntity(e.base+index, 0)
+		if index < uint64(len(e.entities)) {
+			if e.entities[index] != reservedEntity {
+				// created by New or Insert after the reservations
+				continue
+			}
+			e.entities[index] = entity
+			e.gaps--
+		} else if _, inserted := e.ahead[index]; inserted {
+			// skipped by Reserve
+			e.grow(index + 1)
+			continue
+		} else {
+			e.entities = append(e.entities, entity)
+		}
+		fn(entity)
+	}
+	e.synced = fresh
+}
+
 func (e *entityPool) Recycle(entity EntityID) bool {
 	if !e.IsAlive(entity) {
 		return false
 	}
-	index := entity.ID()
+	index := entity.ID() - e.base
+
+	if entity.Gen() == EntityMaxGeneration {
+		*e.slot(index) = retiredEntity
+		e.retired++
+		return true
+	}
+
+	e.pushRecycled(entity.ID(), entity.Gen()+1)
+	return true
+}
 
+// pushRecycled adds the slot of the ID to the recycle list, using the generation when reused
+func (e *entityPool) pushRecycled(id, gen uint64) {
 	e.available++
-	e.entities[index] = MakeEntity(e.next, entity.Gen()+1)
-	e.next = index
+	if !e.policy.fifo() || e.last == 0 {
+		*e.slot(id - e.base) = MakeEntity(e.next, gen)
+		e.next = id
+		if e.last == 0 {
+			e.last = id
+		}
+	} else {
+		*e.slot(id - e.base) = MakeEntity(0, gen)
+		last := e.slot(e.last - e.base)
+		*last = last.SetID(id)
+		e.last = id
+	}
+	if e.stamps != nil {
+		e.stamps = append(e.stamps, recycleStamp{
+			e.allocs + uint64(e.policy.QuarantineAllocations),
+			e.frame + uint64(e.policy.QuarantineFrames),
+		})
+	}
+}
+
+// reusable returns true if the first ID of the recycle list is out of quarantine
+func (e *entityPool) reusable() bool {
+	if e.stamps == nil {
+		return true
+	}
+	stamp := e.stamps[0]
+	return e.allocs > stamp.allocs && e.frame >= stamp.frame
+}
+
+func (e *entityPool) SetRecyclePolicy(policy RecyclePolicy) {
+	e.policy = policy
+	if !policy.quarantine() {
+		e.stamps = nil
+	} else if e.stamps == nil {
+		// the IDs already recycled are out of quarantine
+		e.stamps = make([]recycleStamp, e.available, e.available+1)
+	}
+}
+
+func (e *entityPool) Maintain() {
+	e.frame++
+}
+
+func (e *entityPool) Insert(entity EntityID) bool {
+	entity = entity.WithoutFlags()
+	if entity.ID() <= e.base || entity.ID()-e.base > e.size {
+		return false
+	}
+	index := entity.ID() - e.base
+
+	slot := e.slot(index)
+	if slot == nil {
+		if index < atomic.LoadUint64(&e.fresh) {
+			// reserved and not materialized yet
+			return false
+		}
+		// New and Reserve skip the slot when they reach it
+		if e.ahead == nil {
+			e.ahead = make(map[uint64]*EntityID)
+		}
+		e.ahead[index] = &entity
+		return true
+	}
+
+	recycled := *slot
+	if recycled == reservedEntity || recycled == retiredEntity || recycled.ID() == entity.ID() ||
+		entity.Gen() < recycled.Gen() {
+		return false
+	}
 
+	// unlink the slot from the recycle list
+	prev, position := uint64(0), 0
+	for id := e.next; id != entity.ID(); id = e.slot(id - e.base).ID() {
+		prev = id
+		position++
+	}
+	if prev == 0 {
+		e.next = recycled.ID()
+	} else {
+		link := e.slot(prev - e.base)
+		*link = link.SetID(recycled.ID())
+	}
+	if e.last == entity.ID() {
+		e.last = prev
+	}
+	if e.stamps != nil {
+		e.stamps = append(e.stamps[:position], e.stamps[position+1:]...)
+	}
+	e.available--
+	*slot = entity
 	return true
 }
 
-func (e entityPool) IsAlive(entity EntityID) bool {
-	index := entity.ID()
-	if index == 0 ||
-		index >= uint64(len(e.entities)) {
+func (e *entityPool) IsAlive(entity EntityID) bool {
+	index := entity.ID() - e.base
+	if entity.ID() <= e.base {
 		return false
 	}
-	return e.entities[entity.ID()] == entity.WithoutFlags()
+	if index < uint64(len(e.entities)) {
+		return e.entities[index] == entity.WithoutFlags()
+	}
+	slot := e.ahead[index]
+	return slot != nil && *slot == entity.WithoutFlags()
+}
+
+func (e *entityPool) Stats() EntityPoolStats {
+	fresh := atomic.LoadUint64(&e.fresh)
+	total := uint(len(e.entities)-1) - uint(e.gaps)
+	skipped := uint(0) // inserted slots skipped by Reserve, that are not reservations
+	for index := range e.ahead {
+		if index < fresh {
+			skipped++
+		}
+	}
+	return EntityPoolStats{
+		total + uint(len(e.ahead)) - uint(e.available) - uint(e.retired),
+		uint(e.available),
+		uint(fresh-1) - total - skipped,
+		uint(e.retired),
+		uint(cap(e.entities) - 1),
+	}
+}
+
+func (e *entityPool) Len() uint {
+	return e.Stats().Alive
+}
+
+func (e *entityPool) Each(fn func(EntityID)) {
+	// recycled, retired and reserved slots never store their own ID
+	for index := 1; index < len(e.entities); index++ {
+		if entity := e.entities[index]; entity.ID() == e.base+uint64(index) {
+			fn(entity)
+		}
+	}
+
+	ahead := make([]uint64, 0, len(e.ahead))
+	for index := range e.ahead {
+		ahead = append(ahead, index)
+	}
+	sort.Slice(ahead, func(i, j int) bool { return ahead[i] < ahead[j] })
+	for _, index := range ahead {
+		if entity := *e.ahead[index]; entity.ID() == e.base+index {
+			fn(entity)
+		}
+	}
 }
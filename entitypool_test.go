@@ -1,6 +1,7 @@
 package ecs
 
 import (
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -55,3 +56,303 @@ func TestEntityPoolRecycle(t *testing.T) {
 	}
 
 }
+
+func TestEntityPoolStats(t *testing.T) {
+	ep := NewEntityPool(10)
+
+	entities := make([]EntityID, 0)
+	for i := 0; i < 5; i++ {
+		entities = append(entities, ep.New())
+	}
+	ep.Recycle(entities[1])
+	ep.Recycle(entities[3])
+
+	assert.Equal(t, EntityPoolStats{3, 2, 0, 0, 10}, ep.Stats(), "expected Stats() to count alive and recycled entities")
+
+	ep.New()
+	assert.Equal(t, EntityPoolStats{4, 1, 0, 0, 10}, ep.Stats(), "expected Stats() to count reused entities as alive")
+}
+
+func TestEntityPoolReserve(t *testing.T) {
+	const workers, perWorker = 8, 1000
+
+	ep := NewEntityPool(10)
+	first := ep.New()
+	ep.Recycle(first)
+
+	reserved := make([][]EntityID, workers)
+	var wg sync.WaitGroup
+	for w := range reserved {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				reserved[w] = append(reserved[w], ep.Reserve())
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	unique := make(map[EntityID]bool)
+	for _, entities := range reserved {
+		for _, e := range entities {
+			assert.False(t, unique[e], "expected Reserve() to return unique IDs")
+			assert.False(t, ep.IsAlive(e), "expected reserved IDs to not be alive before Materialize()")
+			unique[e] = true
+		}
+	}
+	assert.Equal(t, EntityPoolStats{0, 1, workers * perWorker, 0, 10}, ep.Stats(), "expected Stats() to count reserved IDs")
+
+	created := ep.New()
+	assert.Equal(t, first.ID(), created.ID(), "expected New() to reuse recycled IDs before new ones")
+	created = ep.New()
+	assert.False(t, unique[created], "expected New() to not return reserved IDs")
+
+	materialized := 0
+	ep.Materialize(func(e EntityID) {
+		assert.True(t, unique[e], "expected Materialize() to return only reserved IDs")
+		materialized++
+	})
+	assert.Equal(t, workers*perWorker, materialized, "expected Materialize() to return all the reserved IDs")
+	for e := range unique {
+		assert.True(t, ep.IsAlive(e), "expected reserved IDs to be alive after Materialize()")
+	}
+	assert.Equal(t, uint(workers*perWorker+2), ep.Stats().Alive, "expected Stats() to count materialized IDs as alive")
+	assert.Zero(t, ep.Stats().Reserved, "expected Stats() to not count materialized IDs as reserved")
+
+	ep.Materialize(func(e EntityID) {
+		assert.Fail(t, "expected Materialize() to return the IDs only once")
+	})
+}
+
+func TestEntityPoolGenerationOverflow(t *testing.T) {
+	ep := NewEntityPool(10)
+
+	old := ep.New()
+	ep.Recycle(old)
+
+	// fast forward the slot to the last generation instead of recycling it 16M times
+	pool := ep.(*entityPool)
+	last := ep.New()
+	assert.Equal(t, old.ID(), last.ID(), "expected New() to reuse the recycled ID")
+	last = MakeEntity(last.ID(), EntityMaxGeneration)
+	pool.entities[last.ID()] = last
+	assert.True(t, ep.IsAlive(last), "expected IsAlive() to return true for the last generation")
+
+	assert.True(t, ep.Recycle(last), "expected Recycle() to return true for the last generation")
+	assert.False(t, ep.IsAlive(last), "expected IsAlive() to return false for retired entities")
+	assert.False(t, ep.Recycle(last), "expected Recycle() to return false for retired entities")
+	assert.Equal(t, EntityPoolStats{0, 0, 0, 1, 10}, ep.Stats(), "expected Stats() to count retired IDs")
+
+	for i := 0; i < 100; i++ {
+		e := ep.New()
+		assert.NotEqual(t, old.ID(), e.ID(), "expected New() to never reuse retired IDs")
+		ep.Recycle(e)
+	}
+	assert.False(t, ep.IsAlive(old), "expected the first generation to stay dead after the generation is exhausted")
+	assert.False(t, ep.IsAlive(MakeEntity(old.ID(), 0)), "expected wrapped generations to stay dead")
+}
+
+func TestEntityPoolEach(t *testing.T) {
+	ep := NewEntityPool(10)
+
+	entities := make([]EntityID, 0)
+	for i := 0; i < 10; i++ {
+		entities = append(entities, ep.New())
+	}
+	ep.Recycle(entities[7])
+	entities[7] = ep.New()
+	ep.Recycle(entities[2])
+	ep.Recycle(entities[5])
+	ep.Reserve()
+
+	alive := []EntityID{}
+	ep.Each(func(e EntityID) {
+		alive = append(alive, e)
+	})
+	want := []EntityID{entities[0], entities[1], entities[3], entities[4], entities[6], entities[7], entities[8], entities[9]}
+	assert.Equal(t, want, alive, "expected Each() to return only the alive entities in ID order")
+	assert.EqualValues(t, len(want), ep.Len(), "expected Len() to count the alive entities")
+
+	ep.Materialize(func(EntityID) {})
+	assert.EqualValues(t, len(want)+1, ep.Len(), "expected Len() to count materialized entities")
+}
+
+func TestEntityPoolInsert(t *testing.T) {
+	ep := NewEntityPool(10)
+
+	e1 := ep.New()
+	assert.False(t, ep.Insert(e1), "expected Insert() to return false for alive entities")
+
+	inserted := MakeEntity(5, 3)
+	assert.True(t, ep.Insert(inserted.Disable()), "expected Insert() to accept IDs never used")
+	assert.True(t, ep.IsAlive(inserted), "expected inserted entities to be alive with their generation")
+	assert.False(t, ep.IsAlive(MakeEntity(5, 0)), "expected inserted entities to be alive only with their generation")
+	assert.Equal(t, EntityPoolStats{2, 0, 0, 0, 10}, ep.Stats(), "expected Insert() to keep the IDs skipped for New()")
+
+	created := map[uint64]bool{}
+	for i := 0; i < 4; i++ {
+		created[ep.New().ID()] = true
+	}
+	assert.Equal(t, map[uint64]bool{2: true, 3: true, 4: true, 6: true}, created, "expected New() to use the IDs skipped by Insert()")
+
+	ep.Recycle(MakeEntity(3, 0))
+	ep.Recycle(MakeEntity(2, 0))
+	ep.Recycle(MakeEntity(4, 0))
+	assert.True(t, ep.Insert(MakeEntity(2, 7)), "expected Insert() to accept recycled IDs")
+	assert.True(t, ep.IsAlive(MakeEntity(2, 7)), "expected inserted entities to be alive")
+	assert.EqualValues(t, 2, ep.Stats().Recycled, "expected Insert() to remove the ID from the recycle list")
+	assert.EqualValues(t, 4, ep.New().ID(), "expected the recycle list to keep the order after Insert()")
+	assert.EqualValues(t, 3, ep.New().ID(), "expected the recycle list to keep the order after Insert()")
+	assert.EqualValues(t, 7, ep.New().ID(), "expected New() to create IDs after the inserted ones")
+
+	reserved := ep.Reserve()
+	assert.False(t, ep.Insert(reserved), "expected Insert() to return false for reserved IDs")
+	assert.False(t, ep.Insert(MakeEntity(EntityIdentifierMask+1, 0)), "expected Insert() to return false for invalid IDs")
+
+	ep.Recycle(MakeEntity(2, 7))
+	assert.False(t, ep.Insert(MakeEntity(2, 6)), "expected Insert() to return false for stale generations")
+	assert.False(t, ep.Insert(MakeEntity(2, 7)), "expected Insert() to return false for removed entities")
+	assert.True(t, ep.Insert(MakeEntity(2, 8)), "expected Insert() to accept the next generations")
+
+	// the last ID of the recycle list with the last generation is not retired
+	last := NewEntityPool(10)
+	id := last.New().ID()
+	last.Recycle(MakeEntity(id, 0))
+	assert.True(t, last.Insert(MakeEntity(id, EntityMaxGeneration-1)), "expected Insert() to accept the next generations")
+	last.Recycle(MakeEntity(id, EntityMaxGeneration-1))
+	assert.True(t, last.Insert(MakeEntity(id, EntityMaxGeneration)), "expected Insert() to accept the last generation")
+
+	far := MakeEntity(EntityIdentifierMask-1, 2)
+	assert.True(t, ep.Insert(far), "expected Insert() to accept IDs far from the ones used")
+	assert.True(t, ep.IsAlive(far), "expected IDs far from the ones used to be alive")
+	assert.EqualValues(t, 10, ep.Stats().Cap, "expected Insert() to not allocate the IDs skipped")
+	assert.False(t, ep.Insert(MakeEntity(far.ID(), 3)), "expected Insert() to return false for alive IDs far from the ones used")
+
+	ep.Materialize(func(EntityID) {})
+	next := ep.Reserve()
+	ep.Materialize(func(e EntityID) { assert.Equal(t, next, e, "expected Materialize() to make the reserved IDs alive") })
+
+	alive := []EntityID{}
+	ep.Each(func(e EntityID) { alive = append(alive, e) })
+	assert.Equal(t, far, alive[len(alive)-1], "expected Each() to return the IDs far from the ones used in order")
+	assert.EqualValues(t, len(alive), ep.Len(), "expected Len() to count the IDs far from the ones used")
+
+	assert.True(t, ep.Recycle(far), "expected Recycle() to accept IDs far from the ones used")
+	assert.False(t, ep.IsAlive(far), "expected Recycle() to remove IDs far from the ones used")
+	assert.Equal(t, MakeEntity(far.ID(), 3), ep.New(), "expected New() to reuse the IDs far from the ones used")
+
+	ep = NewEntityPool(10)
+	assert.True(t, ep.Insert(MakeEntity(2, 1)), "expected Insert() to accept IDs never used")
+	reservations := []EntityID{ep.Reserve(), ep.Reserve()}
+	assert.Equal(t, []EntityID{MakeEntity(1, 0), MakeEntity(3, 0)}, reservations, "expected Reserve() to skip the inserted IDs")
+	assert.Equal(t, EntityPoolStats{1, 0, 2, 0, 10}, ep.Stats(), "expected Stats() to not count the inserted IDs as reserved")
+	ep.Materialize(func(EntityID) {})
+	assert.Equal(t, EntityPoolStats{3, 0, 0, 0, 10}, ep.Stats(), "expected Materialize() to keep the inserted IDs")
+	assert.True(t, ep.IsAlive(MakeEntity(2, 1)), "expected Materialize() to keep the inserted IDs")
+}
+
+func TestEntityPoolRange(t *testing.T) {
+	ep := NewEntityPoolRange(10, EntityRange{100, 102})
+	for i := uint64(100); i <= 102; i++ {
+		assert.Equal(t, i, ep.New().ID(), "expected New() to create IDs in the range")
+	}
+	assert.Panics(t, func() { ep.New() }, "expected New() to panic when the range is exhausted")
+
+	ep.Recycle(MakeEntity(101, 0))
+	assert.Equal(t, MakeEntity(101, 1), ep.New(), "expected New() to reuse the IDs in the range")
+	assert.False(t, ep.Insert(MakeEntity(99, 0)), "expected Insert() to return false for IDs out of the range")
+	assert.False(t, ep.IsAlive(MakeEntity(1, 0)), "expected IsAlive() to return false for IDs out of the range")
+	assert.Panics(t, func() { NewEntityPoolRange(0, EntityRange{0, 10}) }, "expected the ID 0 to be invalid")
+
+	server := NewEntityPoolRange(0, EntityRangeLow)
+	client := NewPartitionedEntityPool(0, 1, EntityRangeLow, EntityRangeHigh)
+
+	predicted := client.New()
+	assert.True(t, EntityRangeHigh.Contains(predicted), "expected New() to create IDs in the allocation range")
+	authoritative := server.New()
+	assert.True(t, client.Insert(authoritative), "expected Insert() to accept the IDs of the other ranges")
+	assert.True(t, client.IsAlive(authoritative) && client.IsAlive(predicted), "expected entities of all ranges to be alive")
+	assert.True(t, client.Insert(MakeEntity(EntityRangeHighBit+10, 0)), "expected Insert() to accept IDs of the allocation range")
+
+	alive := []EntityID{}
+	client.Each(func(e EntityID) { alive = append(alive, e) })
+	assert.Equal(t, []EntityID{authoritative, predicted}, alive[:2], "expected Each() to return the entities in ID order")
+	assert.EqualValues(t, 3, client.Len(), "expected Len() to count the entities of all ranges")
+
+	assert.True(t, client.Recycle(authoritative), "expected Recycle() to use the range of the entity")
+	assert.False(t, client.IsAlive(authoritative), "expected Recycle() to use the range of the entity")
+	assert.NotEqual(t, authoritative.ID(), client.New().ID(), "expected New() to only use the allocation range")
+	assert.False(t, client.Recycle(MakeEntity(0, 0)), "expected Recycle() to return false for IDs out of the ranges")
+	assert.False(t, client.IsAlive(MakeEntity(0, 0)), "expected IsAlive() to return false for IDs out of the ranges")
+
+	reserved := client.Reserve()
+	assert.True(t, EntityRangeHigh.Contains(reserved), "expected Reserve() to use the allocation range")
+	assert.False(t, client.IsAlive(reserved), "expected reserved IDs to not be alive before Materialize()")
+	client.Materialize(func(e EntityID) {
+		assert.Equal(t, reserved, e, "expected Materialize() to make the reserved IDs alive")
+	})
+	assert.True(t, client.IsAlive(reserved), "expected Materialize() to make the reserved IDs alive")
+
+	assert.Panics(t, func() {
+		NewPartitionedEntityPool(0, 0, EntityRange{1, 10}, EntityRange{5, 20})
+	}, "expected overlapping ranges to panic")
+	assert.Panics(t, func() {
+		NewPartitionedEntityPool(0, 2, EntityRangeLow, EntityRangeHigh)
+	}, "expected an allocation range out of the ranges to panic")
+}
+
+func TestEntityPoolRecyclePolicy(t *testing.T) {
+	newPool := func(policy RecyclePolicy) (EntityPool, []EntityID) {
+		ep := NewEntityPool(10)
+		ep.SetRecyclePolicy(policy)
+		entities := []EntityID{ep.New(), ep.New(), ep.New()}
+		for _, e := range entities {
+			ep.Recycle(e)
+		}
+		return ep, entities
+	}
+
+	ep, entities := newPool(RecyclePolicy{})
+	assert.Equal(t, entities[2].ID(), ep.New().ID(), "expected the default policy to reuse the last ID recycled")
+
+	ep, entities = newPool(RecyclePolicy{FIFO: true})
+	for _, e := range entities {
+		assert.Equal(t, e.ID(), ep.New().ID(), "expected FIFO to reuse the oldest ID recycled")
+	}
+	ep.Recycle(MakeEntity(entities[1].ID(), 1))
+	ep.Recycle(MakeEntity(entities[0].ID(), 1))
+	assert.Equal(t, entities[1].ID(), ep.New().ID(), "expected FIFO to keep working after the list is empty")
+
+	ep, entities = newPool(RecyclePolicy{QuarantineAllocations: 2})
+	assert.EqualValues(t, 4, ep.New().ID(), "expected New() to create fresh IDs during the quarantine")
+	assert.EqualValues(t, 5, ep.New().ID(), "expected New() to create fresh IDs during the quarantine")
+	assert.Equal(t, entities[0].ID(), ep.New().ID(), "expected New() to reuse the IDs after QuarantineAllocations")
+
+	ep, entities = newPool(RecyclePolicy{QuarantineFrames: 2})
+	assert.EqualValues(t, 4, ep.New().ID(), "expected New() to create fresh IDs during the quarantine")
+	ep.Maintain()
+	assert.EqualValues(t, 5, ep.New().ID(), "expected New() to create fresh IDs during the quarantine")
+	ep.Maintain()
+	assert.Equal(t, entities[0].ID(), ep.New().ID(), "expected New() to reuse the IDs after QuarantineFrames")
+	assert.True(t, ep.Insert(MakeEntity(entities[2].ID(), 5)), "expected Insert() to remove IDs in quarantine")
+	assert.Equal(t, entities[1].ID(), ep.New().ID(), "expected New() to reuse the IDs after QuarantineFrames")
+	assert.EqualValues(t, 6, ep.New().ID(), "expected the recycle list to be empty")
+
+	ep, entities = newPool(RecyclePolicy{})
+	ep.SetRecyclePolicy(RecyclePolicy{QuarantineAllocations: 10})
+	reused := ep.New()
+	assert.Equal(t, entities[2].ID(), reused.ID(), "expected IDs recycled before the quarantine to be reused")
+	ep.Recycle(reused)
+	assert.Equal(t, entities[1].ID(), ep.New().ID(), "expected IDs recycled before the quarantine to be reused")
+	assert.Equal(t, entities[0].ID(), ep.New().ID(), "expected IDs recycled before the quarantine to be reused")
+	assert.EqualValues(t, 4, ep.New().ID(), "expected New() to create fresh IDs during the quarantine")
+
+	partitioned := NewPartitionedEntityPool(10, 1, EntityRangeLow, EntityRangeHigh)
+	partitioned.SetRecyclePolicy(RecyclePolicy{QuarantineFrames: 1})
+	first := partitioned.New()
+	partitioned.Recycle(first)
+	assert.NotEqual(t, first.ID(), partitioned.New().ID(), "expected the policy to be used by the partitioned pools")
+	partitioned.Maintain()
+	assert.Equal(t, first.ID(), partitioned.New().ID(), "expected Maintain() to count the frames of the partitioned pools")
+}
@@ -34,7 +34,7 @@ func main() {
 	componentFactory.Register(ecs.NewComponentRegistry[Size](SizeComponentID))
 	componentFactory.Register(ecs.NewComponentRegistry[Camera](CameraComponentID))
 
-	// Tag components without data are valid components:
+	// Tag components without data are valid components and don't allocate memory:
 	componentFactory.Register(ecs.NewComponentRegistry[Controllable](ControllableComponentID))
 
 	// We can have singleton components. It'll be created when the first entity needs it and
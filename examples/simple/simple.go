@@ -27,7 +27,7 @@ func main() {
 	world.Register(ecs.NewComponentRegistry[Size](SizeComponentID))
 	world.Register(ecs.NewComponentRegistry[Camera](CameraComponentID))
 
-	// Tag components without data are valid components:
+	// Tag components without data are valid components and don't allocate memory:
 	world.Register(ecs.NewComponentRegistry[Controllable](ControllableComponentID))
 
 	// We can have singleton components. It'll be created when the first entity needs it and
@@ -0,0 +1,155 @@
+package ecs
+
+import (
+	"math/bits"
+	"unsafe"
+)
+
+// DynamicMask defines an array of bits without size limit, growing as needed when bits are set.
+// It's used by the unconstrained ArchetypeGraph to handle more than MaskTotalBits components
+type DynamicMask []uint64
+
+// MakeDynamicMask creates a new dynamic bitmask from a list of bits
+func MakeDynamicMask(bits ...uint64) DynamicMask {
+	mask := DynamicMask{}
+	for _, bit := range bits {
+		mask.Set(bit)
+	}
+	return mask
+}
+
+// Dynamic returns a DynamicMask with the same bits set as the mask
+func (m Mask) Dynamic() DynamicMask {
+	mask := make(DynamicMask, len(m))
+	copy(mask, m[:])
+	return mask
+}
+
+// Set sets the bit in the mask, growing it if needed
+func (m *DynamicMask) Set(bit uint64) {
+	word := int(bit >> 6)
+	if word >= len(*m) {
+		grown := make(DynamicMask, word+1)
+		copy(grown, *m)
+		*m = grown
+	}
+	(*m)[word] |= (1 << (bit & 63))
+}
+
+// Clear clear the bit in the mask
+func (m *DynamicMask) Clear(bit uint64) {
+	word := int(bit >> 6)
+	if word < len(*m) {
+		(*m)[word] &= ^(1 << (bit & 63))
+	}
+}
+
+// IsSet returns if the bit is set in the mask
+func (m DynamicMask) IsSet(bit uint64) bool {
+	word := int(bit >> 6)
+	if word >= len(m) {
+		return false
+	}
+	return m[word]&(1<<(bit&63)) != 0
+}
+
+// IsEmpty returns true if no bit is set in the mask
+func (m DynamicMask) IsEmpty() bool {
+	for _, v := range m {
+		if v != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Clone returns a copy of the mask
+func (m DynamicMask) Clone() DynamicMask {
+	mask := make(DynamicMask, len(m))
+	copy(mask, m)
+	return mask
+}
+
+// And returns a new mask with the result of the operator AND between the mask and the argument
+func (m DynamicMask) And(mask DynamicMask) DynamicMask {
+	size := len(m)
+	if len(mask) < size {
+		size = len(mask)
+	}
+	newMask := make(DynamicMask, size)
+	for i := range newMask {
+		newMask[i] = m[i] & mask[i]
+	}
+	return newMask
+}
+
+// Contains returns true if the mask contains all the bits set in the submask argument
+func (m DynamicMask) Contains(sub DynamicMask) bool {
+	for i, v := range sub {
+		if i >= len(m) {
+			if v != 0 {
+				return false
+			}
+			continue
+		}
+		if m[i]&v != v {
+			return false
+		}
+	}
+	return true
+}
+
+// TotalBitsSet returns how many bits are set in this mask
+func (m DynamicMask) TotalBitsSet() uint {
+	acc := 0
+	for _, v := range m {
+		acc += bits.OnesCount64(v)
+	}
+	return uint(acc)
+}
+
+// TotalBits returns the size of the mask in bits
+func (m DynamicMask) TotalBits() uint {
+	return uint(len(m)) << 6
+}
+
+// NextBitSet returns the index of the next bit set in range [startingFromBit, TotalBits]
+// If no bit set is found within this range, the return is TotalBits
+func (m DynamicMask) NextBitSet(startingFromBit uint) uint {
+	word := startingFromBit >> 6
+	if word >= uint(len(m)) {
+		return m.TotalBits()
+	}
+
+	e := m[word] >> (startingFromBit & 63)
+	if e != 0 {
+		return startingFromBit + uint(bits.TrailingZeros64(e))
+	}
+
+	for word++; word < uint(len(m)); word++ {
+		if m[word] != 0 {
+			return (word << 6) + uint(bits.TrailingZeros64(m[word]))
+		}
+	}
+	return m.TotalBits()
+}
+
+// Mask returns a Mask with the bits lower than MaskTotalBits
+func (m DynamicMask) Mask() Mask {
+	mask := Mask{}
+	copy(mask[:], m)
+	return mask
+}
+
+// Key returns a string that identifies the bits set in the mask, to be used as map key.
+// Masks with the same bits set have the same key, regardless of their size
+func (m DynamicMask) Key() string {
+	size := len(m)
+	for size > 0 && m[size-1] == 0 {
+		size--
+	}
+	if size == 0 {
+		return ""
+	}
+	return string(unsafe.Slice((*byte)(unsafe.Pointer(&m[0])), size*8))
+}
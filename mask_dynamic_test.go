@@ -0,0 +1,81 @@
+package ecs
+
+import (
+	"testing"
+)
+
+func TestDynamicBitmaskGetSetClear(t *testing.T) {
+	bits := []uint64{0, 1, 5, 63, 64, 255, 256, 300, 1000, 4097}
+	mask := MakeDynamicMask(bits...)
+
+	if mask.TotalBitsSet() != uint(len(bits)) {
+		t.Error("expected ", len(bits), " bits set, got ", mask.TotalBitsSet())
+	}
+
+	next := mask.NextBitSet(0)
+	for _, bit := range bits {
+		if !mask.IsSet(bit) {
+			t.Error("bit don't have true value at index ", bit)
+		}
+		if next != uint(bit) {
+			t.Error("expected NextBitSet to return ", bit, ", got ", next)
+		}
+		next = mask.NextBitSet(next + 1)
+	}
+	if next != mask.TotalBits() || mask.NextBitSet(mask.TotalBits()+10) != mask.TotalBits() {
+		t.Error("expected NextBitSet to return TotalBits when no more bits are set")
+	}
+
+	clone := mask.Clone()
+	for _, bit := range bits {
+		clone.Clear(bit)
+		if clone.IsSet(bit) {
+			t.Error("bit not cleared correctly at index ", bit)
+		}
+	}
+	clone.Clear(100000)
+	if !clone.IsEmpty() || mask.IsEmpty() {
+		t.Error("expected Clear to change only the cloned mask")
+	}
+	if clone.IsSet(100000) {
+		t.Error("expected out of range bits to return false")
+	}
+}
+
+func TestDynamicBitmaskOperations(t *testing.T) {
+	mask := MakeDynamicMask(1, 2, 3, 9, 10, 300, 700)
+	valid := MakeDynamicMask(1, 3, 300)
+	invalid := MakeDynamicMask(1, 3, 301)
+	bigger := MakeDynamicMask(1, 3, 3000)
+
+	if !mask.Contains(valid) {
+		t.Error("expected mask to contain ", valid)
+	}
+	if mask.Contains(invalid) || mask.Contains(bigger) {
+		t.Error("expected mask to not contain invalid masks")
+	}
+	if !mask.Contains(append(valid.Clone(), 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0)) {
+		t.Error("expected Contains to ignore empty words")
+	}
+
+	and := mask.And(bigger)
+	if and.Key() != MakeDynamicMask(1, 3).Key() {
+		t.Error("expected And to return the common bits, got ", and)
+	}
+	and = bigger.And(mask)
+	if and.Key() != MakeDynamicMask(1, 3).Key() {
+		t.Error("expected And to return the common bits, got ", and)
+	}
+
+	if valid.Key() == invalid.Key() || (DynamicMask{}).Key() != (DynamicMask{0, 0}).Key() {
+		t.Error("expected Key to identify the bits set")
+	}
+
+	low := MakeMask(1, 2, 3, 9, 10)
+	if mask.Mask() != low {
+		t.Error("expected Mask to return the bits lower than MaskTotalBits")
+	}
+	if low.Dynamic().Key() != MakeDynamicMask(1, 2, 3, 9, 10).Key() {
+		t.Error("expected Dynamic to return the same bits set")
+	}
+}
@@ -135,3 +135,12 @@ func TestBitmaskSearchAndCount(t *testing.T) {
 		randomBits = randomBits[:0]
 	}
 }
+
+func TestBitmaskAnd(t *testing.T) {
+	mask := MakeMask(1, 2, 3, 70, 200)
+	other := MakeMask(2, 3, 4, 200, 201)
+
+	if mask.And(other) != MakeMask(2, 3, 200) {
+		t.Error("expected And to return the common bits, got ", mask.And(other))
+	}
+}
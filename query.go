@@ -9,16 +9,41 @@ Use this implementation in critical parts of your project
 to access the entity ID and components in an efficient way
 */
 type QueryCursor struct {
+	graph       *archetypeGraph
+	version     uint64
 	archetypes  []Archetype
 	arch        *Archetype
 	mask        Mask
+	dynMask     DynamicMask
+	sparse      []*sparseSet
 	archIndex   int
 	entityIndex int
 	entityTotal int
+	moves       []uint64 // ecsdebug builds: the moves of every archetype when the query started
 }
 
 // Next returns true if the query have more entities to iterate over
 func (e *QueryCursor) Next() bool {
+	if debugChecks {
+		e.checkStale()
+	}
+	if e.sparse == nil {
+		if e.entityIndex < e.entityTotal {
+			e.entityIndex++
+			return true
+		}
+		return e.next()
+	}
+	for e.next() {
+		if e.inSparseSets(e.arch.entities[e.entityIndex]) {
+			return true
+		}
+	}
+	return false
+}
+
+// next advances to the next entity of the archetypes matching the mask
+func (e *QueryCursor) next() bool {
 	if e.entityIndex < e.entityTotal {
 		e.entityIndex++
 		return true
@@ -27,7 +52,7 @@ func (e *QueryCursor) Next() bool {
 	for e.archIndex < len(e.archetypes) {
 		arch := &e.archetypes[e.archIndex]
 		e.archIndex++
-		if len(arch.entities) > 0 && arch.mask.Contains(e.mask) {
+		if len(arch.entities) > 0 && e.matches(arch) {
 			e.entityIndex = 0
 			e.entityTotal = len(arch.entities) - 1
 			e.arch = arch
@@ -37,9 +62,20 @@ func (e *QueryCursor) Next() bool {
 	return false
 }
 
+// matches returns true if the archetype have all the components of the query
+func (e *QueryCursor) matches(arch *Archetype) bool {
+	return arch.mask.Contains(e.mask) && (e.dynMask == nil || arch.dynMask.Contains(e.dynMask))
+}
+
 // Component returns the component pointer for the actual entity
 func (e *QueryCursor) Component(component ComponentID) unsafe.Pointer {
-	return e.arch.columns[component].Get(uint(e.entityIndex))
+	if debugChecks {
+		e.checkStale()
+	}
+	if column := e.arch.column(component); column != nil {
+		return column.Get(uint(e.entityIndex))
+	}
+	return e.graph.Component(e.Entity(), component)
 }
 
 // Entity returns the EntityID of the actual entity
@@ -49,13 +85,154 @@ func (e *QueryCursor) Entity() EntityID {
 
 // Restart initializes the cursor to the first entity in the query
 func (e *QueryCursor) Restart() {
+	if e.graph != nil {
+		e.version = e.graph.version
+		e.archetypes = e.graph.archetypes
+		if debugChecks {
+			e.moves = e.moves[:0]
+			for i := range e.archetypes {
+				e.moves = append(e.moves, *e.archetypes[i].moves)
+			}
+		}
+	}
 	e.entityIndex = 0
 	e.entityTotal = 0
 	e.archIndex = 0
 }
 
-func (e *QueryCursor) prepare(mask Mask, graph *archetypeGraph) {
+// checkStale panics if entities were removed or moved from the archetypes matched by the query,
+// from the actual one to the last, after the query started. Changes in other archetypes are allowed
+func (e *QueryCursor) checkStale() {
+	if e.graph == nil || e.graph.version == e.version {
+		return
+	}
+	start := e.archIndex - 1
+	if start < 0 {
+		start = 0
+	}
+	for i := start; i < len(e.archetypes); i++ {
+		arch := &e.archetypes[i]
+		if *arch.moves != e.moves[i] && e.matches(arch) {
+			panic("ecs: query used after structural changes in the world (defer the changes until the iteration ends)")
+		}
+	}
+	e.version = e.graph.version
+}
+
+// inSparseSets returns true if the entity is in all the sparse sets used by the query
+func (e *QueryCursor) inSparseSets(entity EntityID) bool {
+	for _, set := range e.sparse {
+		if !set.has(entity) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *QueryCursor) prepare(mask Mask, dynMask DynamicMask, sparse []*sparseSet, graph *archetypeGraph) {
+	e.graph = graph
+	e.version = graph.version
 	e.archetypes = graph.archetypes
 	e.mask = mask
+	e.dynMask = dynMask
+	e.sparse = sparse
 	e.Restart()
 }
+
+/*
+SharedQueryCursor iterates over the entities of a query grouped by the value of a component,
+usually a shared component (see NewSharedComponentRegistry[T]).
+
+Use NextGroup to advance to the next distinct value and Next to iterate over its entities:
+
+	groups := world.Query(mask).GroupBy(MaterialComponentID)
+	for groups.NextGroup() {
+		material := (*Material)(groups.Value())
+		for groups.Next() {
+			pos := (*Position)(groups.Component(PositionComponentID))
+		}
+	}
+*/
+type SharedQueryCursor struct {
+	groups      []sharedQueryGroup
+	groupIndex  int
+	entityIndex int
+}
+
+type sharedQueryGroup struct {
+	value unsafe.Pointer
+	rows  []sharedQueryRow
+}
+
+type sharedQueryRow struct {
+	arch *Archetype
+	row  uint32
+}
+
+// GroupBy returns a SharedQueryCursor for the remaining entities of the query, grouped by the
+// address of the component value. Entities without the component are ignored.
+func (e QueryCursor) GroupBy(component ComponentID) SharedQueryCursor {
+	var sc SharedQueryCursor
+	groupIndex := make(map[unsafe.Pointer]int)
+
+	for e.Next() {
+		column := e.arch.column(component)
+		if column == nil {
+			continue
+		}
+		value := column.Get(uint(e.entityIndex))
+		index, ok := groupIndex[value]
+		if !ok {
+			index = len(sc.groups)
+			groupIndex[value] = index
+			sc.groups = append(sc.groups, sharedQueryGroup{value: value})
+		}
+		group := &sc.groups[index]
+		group.rows = append(group.rows, sharedQueryRow{e.arch, uint32(e.entityIndex)})
+	}
+
+	sc.Restart()
+	return sc
+}
+
+// NextGroup returns true if there are more groups to iterate over
+func (s *SharedQueryCursor) NextGroup() bool {
+	if s.groupIndex+1 >= len(s.groups) {
+		return false
+	}
+	s.groupIndex++
+	s.entityIndex = -1
+	return true
+}
+
+// Value returns the pointer to the component value shared by the actual group
+func (s *SharedQueryCursor) Value() unsafe.Pointer {
+	return s.groups[s.groupIndex].value
+}
+
+// Next returns true if the actual group have more entities to iterate over
+func (s *SharedQueryCursor) Next() bool {
+	if s.entityIndex+1 >= len(s.groups[s.groupIndex].rows) {
+		return false
+	}
+	s.entityIndex++
+	return true
+}
+
+// Component returns the component pointer for the actual entity
+func (s *SharedQueryCursor) Component(component ComponentID) unsafe.Pointer {
+	row := s.groups[s.groupIndex].rows[s.entityIndex]
+	return row.arch.column(component).Get(uint(row.row))
+}
+
+// Entity returns the EntityID of the actual entity
+func (s *SharedQueryCursor) Entity() EntityID {
+	row := s.groups[s.groupIndex].rows[s.entityIndex]
+	return row.arch.entities[row.row]
+}
+
+// Restart initializes the cursor to the first group
+func (s *SharedQueryCursor) Restart() {
+	s.groupIndex = -1
+	s.entityIndex = -1
+}
@@ -0,0 +1,42 @@
+package ecs
+
+/*
+Ref is a stable reference to the component of an entity.
+
+The pointers returned by World.Component, Archetype.Component and QueryCursor.Component are only
+valid until the next structural change in the world, as the components can be moved when the
+storage grows or when entities are added to or removed from the archetype.
+Ref keeps the entity and component instead, resolving the current location on every access.
+
+Build with the ecsdebug tag to detect stale *Archetype uses and queries used after the entities of
+the archetypes they match were moved or removed, as the pointers returned by the cursor may be stale.
+*/
+type Ref[T any] struct {
+	world     World
+	entity    EntityID
+	component ComponentID
+}
+
+// NewRef[T] returns a stable reference to the component of the entity in the world
+func NewRef[T any](world World, entity EntityID, component ComponentID) Ref[T] {
+	return Ref[T]{world, entity, component}
+}
+
+// Get returns the current pointer for the component or nil if the entity is not alive
+// or don't have the component anymore
+func (r Ref[T]) Get() *T {
+	if r.world == nil || !r.world.IsAlive(r.entity) {
+		return nil
+	}
+	return (*T)(r.world.Component(r.entity, r.component))
+}
+
+// Entity returns the referenced entity
+func (r Ref[T]) Entity() EntityID {
+	return r.entity
+}
+
+// IsValid returns true if the component can be accessed with Get
+func (r Ref[T]) IsValid() bool {
+	return r.Get() != nil
+}
@@ -0,0 +1,41 @@
+package ecs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRef(t *testing.T) {
+	const (
+		PositionCompID ComponentID = iota
+		HealthCompID
+	)
+	type Position struct{ x, y float32 }
+	type Health struct{ value float32 }
+
+	world := NewWorld(0)
+	world.Register(NewComponentRegistry[Position](PositionCompID))
+	world.Register(NewComponentRegistry[Health](HealthCompID))
+
+	e := world.NewEntity(PositionCompID)
+	ref := NewRef[Position](world, e, PositionCompID)
+	assert.Equal(t, e, ref.Entity(), "Ref.Entity should return the referenced entity")
+	assert.True(t, ref.IsValid(), "Ref should be valid for alive entities with the component")
+	ref.Get().x = 10
+
+	// move the entity and grow the storage, making old pointers stale
+	for i := 0; i < int(ComponentStorageInitialCap)*2; i++ {
+		world.NewEntity(PositionCompID, HealthCompID)
+	}
+	world.AddComponent(e, HealthCompID)
+
+	assert.Equal(t, float32(10), ref.Get().x, "Ref.Get should resolve the current location of the component")
+
+	world.RemComponent(e, PositionCompID)
+	assert.False(t, ref.IsValid(), "Ref should not be valid after removing the component")
+
+	world.RemEntity(e)
+	assert.Nil(t, ref.Get(), "Ref.Get should return nil for removed entities")
+	assert.Nil(t, Ref[Position]{}.Get(), "Ref.Get should return nil for empty references")
+}
@@ -0,0 +1,250 @@
+package ecs
+
+import (
+	"fmt"
+	"sync"
+)
+
+/*
+System is a function scheduled by the Scheduler, declaring the components it reads and writes.
+
+Systems with conflicting access (one writes a component the other reads or writes) never run at
+the same time, and the others run in parallel goroutines. Run must only read and change the
+component values declared in Reads and Writes, and record the structural changes in the CommandBuffer.
+*/
+type System struct {
+	// Name identifies the system in the ordering constraints, it must be unique in the Scheduler
+	Name string
+	// Reads is the mask of the components read by the system
+	Reads Mask
+	// Writes is the mask of the components changed by the system
+	Writes Mask
+	// After lists the systems of the same stage that must finish before this one starts
+	After []string
+	// Run executes the system. The CommandBuffer is applied at the next sync point
+	Run func(w World, commands *CommandBuffer)
+}
+
+// conflicts returns true if the systems can't run at the same time
+func (s *System) conflicts(other *System) bool {
+	return !s.Writes.And(other.Writes).IsEmpty() ||
+		!s.Writes.And(other.Reads).IsEmpty() ||
+		!s.Reads.And(other.Writes).IsEmpty()
+}
+
+/*
+Scheduler runs the systems in stages split by sync points. The systems of a stage run in parallel,
+ordered only by their conflicting access and After constraints. At the end of every stage, the
+CommandBuffers are applied to the world in the order the systems were added, so the structural
+changes are deterministic:
+
+	scheduler := ecs.NewScheduler(world)
+	scheduler.Add(ecs.System{Name: "input", Writes: velocityMask, Run: input})
+	scheduler.Add(ecs.System{Name: "ai", Reads: positionMask, Writes: velocityMask, After: []string{"input"}, Run: ai})
+	scheduler.Add(ecs.System{Name: "audio", Reads: positionMask, Run: audio})
+	scheduler.Sync()
+	scheduler.Add(ecs.System{Name: "movement", Reads: velocityMask, Writes: positionMask, Run: movement})
+
+	for running {
+		scheduler.Run()
+	}
+
+A panic in a system is raised again by Run, after the other systems of the stage finish.
+*/
+type Scheduler struct {
+	world  World
+	stages [][]*schedulerNode
+	names  map[string]bool
+	built  bool
+}
+
+type schedulerNode struct {
+	system   System
+	commands *CommandBuffer
+	deps     []int // nodes of the same stage that must finish before this one
+}
+
+// NewScheduler returns an empty Scheduler for the world
+func NewScheduler(w World) *Scheduler {
+	return &Scheduler{
+		w,
+		[][]*schedulerNode{nil},
+		make(map[string]bool),
+		false,
+	}
+}
+
+// Add appends the system to the current stage. Panics if the name is already in use or Run is nil
+func (s *Scheduler) Add(system System) *Scheduler {
+	if system.Run == nil {
+		panic("Scheduler: system without Run function")
+	}
+	if s.names[system.Name] {
+		panic(fmt.Sprintf("Scheduler: system %q already added", system.Name))
+	}
+	s.names[system.Name] = true
+
+	last := len(s.stages) - 1
+	s.stages[last] = append(s.stages[last], &schedulerNode{system: system, commands: NewCommandBuffer(s.world)})
+	s.built = false
+	return s
+}
+
+// Sync adds a sync point: the commands are applied and the next systems start after the previous ones finish
+func (s *Scheduler) Sync() *Scheduler {
+	if len(s.stages[len(s.stages)-1]) > 0 {
+		s.stages = append(s.stages, nil)
+	}
+	return s
+}
+
+// Build computes the dependencies between the systems, called by Run after the systems change.
+// Panics if an After constraint is not in the same stage or the constraints make a cycle.
+func (s *Scheduler) Build() {
+	for _, stage := range s.stages {
+		index := make(map[string]int, len(stage))
+		for i, node := range stage {
+			index[node.system.Name] = i
+		}
+
+		// explicit constraints
+		after := make([][]bool, len(stage))
+		for i, node := range stage {
+			after[i] = make([]bool, len(stage))
+			for _, name := range node.system.After {
+				dep, ok := index[name]
+				if !ok {
+					panic(fmt.Sprintf("Scheduler: system %q must run after %q, not found in the same stage", node.system.Name, name))
+				}
+				after[i][dep] = true
+			}
+		}
+
+		// reach[i][j] is true when i runs after j by the constraints, directly or through other systems
+		reach := make([][]bool, len(stage))
+		for i := range stage {
+			reach[i] = make([]bool, len(stage))
+			copy(reach[i], after[i])
+		}
+		for k := range stage {
+			for i := range stage {
+				if !reach[i][k] {
+					continue
+				}
+				for j := range stage {
+					reach[i][j] = reach[i][j] || reach[k][j]
+				}
+			}
+		}
+
+		// conflicting systems without constraints between them run in the order they were added
+		for i, node := range stage {
+			for j := 0; j < i; j++ {
+				if reach[i][j] || reach[j][i] || !node.system.conflicts(&stage[j].system) {
+					continue
+				}
+				after[i][j] = true
+				// i and the systems after it now run after j and the systems before it
+				for x := range stage {
+					if x != i && !reach[x][i] {
+						continue
+					}
+					reach[x][j] = true
+					for y := range stage {
+						reach[x][y] = reach[x][y] || reach[j][y]
+					}
+				}
+			}
+		}
+
+		for i, node := range stage {
+			node.deps = node.deps[:0]
+			for dep, ok := range after[i] {
+				if ok {
+					node.deps = append(node.deps, dep)
+				}
+			}
+		}
+		checkSchedulerCycles(stage)
+	}
+	s.built = true
+}
+
+// checkSchedulerCycles panics if the dependencies of the stage make a cycle
+func checkSchedulerCycles(stage []*schedulerNode) {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(stage))
+	var visit func(i int)
+	visit = func(i int) {
+		switch state[i] {
+		case visiting:
+			panic(fmt.Sprintf("Scheduler: cycle in the constraints of system %q", stage[i].system.Name))
+		case visited:
+			return
+		}
+		state[i] = visiting
+		for _, dep := range stage[i].deps {
+			visit(dep)
+		}
+		state[i] = visited
+	}
+	for i := range stage {
+		visit(i)
+	}
+}
+
+// Run executes all the stages once, applying the commands at every sync point
+func (s *Scheduler) Run() {
+	if !s.built {
+		s.Build()
+	}
+	for _, stage := range s.stages {
+		s.runStage(stage)
+		for _, node := range stage {
+			node.commands.Apply()
+		}
+	}
+}
+
+func (s *Scheduler) runStage(stage []*schedulerNode) {
+	if len(stage) == 1 {
+		stage[0].system.Run(s.world, stage[0].commands)
+		return
+	}
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		panicked interface{}
+	)
+	done := make([]chan struct{}, len(stage))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+
+	wg.Add(len(stage))
+	for i, node := range stage {
+		go func(i int, node *schedulerNode) {
+			defer wg.Done()
+			defer close(done[i])
+			defer func() {
+				if r := recover(); r != nil {
+					once.Do(func() { panicked = r })
+				}
+			}()
+			for _, dep := range node.deps {
+				<-done[dep]
+			}
+			node.system.Run(s.world, node.commands)
+		}(i, node)
+	}
+	wg.Wait()
+
+	if panicked != nil {
+		panic(panicked)
+	}
+}
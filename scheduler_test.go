@@ -0,0 +1,201 @@
+package ecs
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduler(t *testing.T) {
+	const (
+		PositionCompID ComponentID = iota
+		VelocityCompID
+		HealthCompID
+	)
+	type Position struct{ x, y float32 }
+	type Velocity struct{ x, y float32 }
+	type Health struct{ value float32 }
+
+	newWorld := func() World {
+		w := NewWorld(0)
+		w.Register(NewComponentRegistry[Position](PositionCompID))
+		w.Register(NewComponentRegistry[Velocity](VelocityCompID))
+		w.Register(NewComponentRegistry[Health](HealthCompID))
+		return w
+	}
+	position := MakeComponentMask(PositionCompID)
+	velocity := MakeComponentMask(VelocityCompID)
+	health := MakeComponentMask(HealthCompID)
+
+	t.Run("Parallel", func(t *testing.T) {
+		// both readers must be running at the same time to finish
+		var barrier sync.WaitGroup
+		barrier.Add(2)
+		started := make(chan struct{})
+		go func() {
+			barrier.Wait()
+			close(started)
+		}()
+		reader := func(name string) System {
+			return System{Name: name, Reads: position, Run: func(World, *CommandBuffer) {
+				barrier.Done()
+				select {
+				case <-started:
+				case <-time.After(5 * time.Second):
+					t.Errorf("expected %s to run in parallel with the other readers", name)
+				}
+			}}
+		}
+		s := NewScheduler(newWorld())
+		s.Add(reader("render")).Add(reader("audio"))
+		s.Run()
+	})
+
+	t.Run("Ordering", func(t *testing.T) {
+		var mu sync.Mutex
+		var order []string
+		system := func(name string, reads, writes Mask, after ...string) System {
+			return System{name, reads, writes, after, func(World, *CommandBuffer) {
+				time.Sleep(time.Millisecond)
+				mu.Lock()
+				order = append(order, name)
+				mu.Unlock()
+			}}
+		}
+		indexOf := func(name string) int {
+			for i, n := range order {
+				if n == name {
+					return i
+				}
+			}
+			return -1
+		}
+
+		s := NewScheduler(newWorld())
+		s.Add(system("ai", position, velocity))
+		s.Add(system("input", Mask{}, velocity, "ai"))
+		s.Add(system("damage", Mask{}, health))
+		s.Add(system("regen", Mask{}, health, "damage"))
+		s.Sync()
+		s.Add(system("movement", velocity, position))
+
+		for i := 0; i < 10; i++ {
+			order = nil
+			s.Run()
+			assert.Len(t, order, 5, "expected all systems to run")
+			assert.Less(t, indexOf("ai"), indexOf("input"), "expected After constraints to be respected")
+			assert.Less(t, indexOf("damage"), indexOf("regen"), "expected conflicting systems in the order they were added")
+			assert.Equal(t, "movement", order[4], "expected sync points to wait for the previous systems")
+		}
+
+		// the constraints order a after c through b, even if c was added after a
+		transitive := NewScheduler(newWorld())
+		transitive.Add(system("a", Mask{}, health, "b"))
+		transitive.Add(system("b", Mask{}, Mask{}, "c"))
+		transitive.Add(system("c", Mask{}, health))
+		assert.NotPanics(t, transitive.Build, "expected conflicts ordered by transitive constraints to not make cycles")
+		order = nil
+		transitive.Run()
+		assert.Equal(t, []string{"c", "b", "a"}, order, "expected the transitive constraints to be respected")
+
+		cyclic := NewScheduler(newWorld())
+		cyclic.Add(system("a", Mask{}, Mask{}, "b")).Add(system("b", Mask{}, Mask{}, "a"))
+		assert.Panics(t, cyclic.Build, "expected cycles in the constraints to panic")
+
+		missing := NewScheduler(newWorld())
+		missing.Add(system("a", Mask{}, Mask{})).Sync().Add(system("b", Mask{}, Mask{}, "a"))
+		assert.Panics(t, missing.Build, "expected constraints with systems of other stages to panic")
+
+		assert.Panics(t, func() {
+			s.Add(system("ai", Mask{}, Mask{}))
+		}, "expected duplicated names to panic")
+		assert.Panics(t, func() {
+			s.Add(System{Name: "empty"})
+		}, "expected systems without Run to panic")
+	})
+
+	t.Run("Commands", func(t *testing.T) {
+		w := newWorld()
+		for i := 0; i < 10; i++ {
+			e := w.NewEntity(PositionCompID, HealthCompID)
+			w.SetComponent(e, HealthCompID, &Health{float32(i)})
+		}
+
+		spawned := 0
+		s := NewScheduler(w)
+		s.Add(System{Name: "death", Reads: health, Run: func(w World, cmd *CommandBuffer) {
+			query := w.Query(health)
+			for query.Next() {
+				if (*Health)(query.Component(HealthCompID)).value < 5 {
+					cmd.RemEntity(query.Entity())
+				}
+			}
+		}})
+		s.Add(System{Name: "spawner", Run: func(w World, cmd *CommandBuffer) {
+			vel := Velocity{1, 1}
+			e := cmd.NewEntity(PositionCompID)
+			cmd.AddComponents(e, VelocityCompID)
+			cmd.SetComponent(e, VelocityCompID, &vel)
+			vel.x = 100
+			spawned++
+		}})
+		s.Sync()
+		s.Add(System{Name: "count", Reads: position, Run: func(w World, cmd *CommandBuffer) {
+			count := 0
+			query := w.Query(position)
+			for query.Next() {
+				count++
+			}
+			assert.Equal(t, 5+spawned, count, "expected the commands to be applied at the sync point")
+		}})
+
+		s.Run()
+		s.Run()
+
+		query := w.Query(velocity)
+		for query.Next() {
+			assert.Equal(t, Velocity{1, 1}, *(*Velocity)(query.Component(VelocityCompID)), "expected SetComponent to copy the value")
+		}
+		assert.EqualValues(t, 7, w.Stats().EntityPool.Alive, "expected the commands applied once")
+	})
+
+	t.Run("DeterministicEntities", func(t *testing.T) {
+		w := newWorld()
+		spawner := func(name string, value float32, delay time.Duration) System {
+			return System{Name: name, Run: func(w World, cmd *CommandBuffer) {
+				time.Sleep(delay)
+				e := cmd.NewEntity(HealthCompID)
+				assert.False(t, w.IsAlive(e), "expected NewEntity to return a placeholder until Apply")
+				cmd.SetComponent(e, HealthCompID, &Health{value})
+			}}
+		}
+		s := NewScheduler(w)
+		s.Add(spawner("slow", 0, 5*time.Millisecond)).Add(spawner("fast", 1, 0))
+
+		for i := 0; i < 5; i++ {
+			s.Run()
+		}
+		var entities []EntityID
+		query := w.Query(health)
+		for query.Next() {
+			entities = append(entities, query.Entity())
+			value := float32((len(entities) - 1) % 2)
+			assert.Equal(t, Health{value}, *(*Health)(query.Component(HealthCompID)), "expected the commands applied to the entities created")
+		}
+		assert.Len(t, entities, 10, "expected all entities created")
+		for i := 1; i < len(entities); i++ {
+			assert.Less(t, entities[i-1].ID(), entities[i].ID(), "expected the entities created in the order the systems were added")
+		}
+	})
+
+	t.Run("Panic", func(t *testing.T) {
+		ran := false
+		s := NewScheduler(newWorld())
+		s.Add(System{Name: "broken", Writes: position, Run: func(World, *CommandBuffer) { panic("broken system") }})
+		s.Add(System{Name: "other", Writes: velocity, Run: func(World, *CommandBuffer) { ran = true }})
+		assert.PanicsWithValue(t, "broken system", s.Run, "expected Run to raise the system panic")
+		assert.True(t, ran, "expected the other systems to finish")
+	})
+}
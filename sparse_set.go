@@ -0,0 +1,165 @@
+package ecs
+
+const (
+	sparseSetPageBits = 12 // 4096 IDs per page
+	sparseSetPageSize = 1 << sparseSetPageBits
+	sparseSetPageMask = sparseSetPageSize - 1
+)
+
+/*
+sparseSet stores the values of a sparse component, outside of the archetypes.
+
+Entities are added and removed in O(1) without moving between archetypes, so it's useful for
+components that are frequently toggled. The sparse pages map the EntityID.ID() to the row+1
+of the entity in the dense list, where zero means the entity don't have the component.
+The pages are allocated on first use, like in the entityIndex, so IDs far apart, like the
+ones from EntityRangeHigh, don't allocate the pages between them.
+*/
+type sparseSet struct {
+	sparse  []*[sparseSetPageSize]uint32
+	dense   []EntityID
+	storage Storage
+}
+
+func newSparseSet(storage Storage) *sparseSet {
+	return &sparseSet{
+		sparse:  nil,
+		dense:   make([]EntityID, 0),
+		storage: storage,
+	}
+}
+
+// slot returns the pointer to the row+1 of the ID, allocating the page if alloc is true.
+// Returns nil if the page is not allocated
+func (s *sparseSet) slot(id uint64, alloc bool) *uint32 {
+	page := id >> sparseSetPageBits
+	if page >= uint64(len(s.sparse)) {
+		if !alloc {
+			return nil
+		}
+		sparse := make([]*[sparseSetPageSize]uint32, page+1)
+		copy(sparse, s.sparse)
+		s.sparse = sparse
+	}
+	if s.sparse[page] == nil {
+		if !alloc {
+			return nil
+		}
+		s.sparse[page] = new([sparseSetPageSize]uint32)
+	}
+	return &s.sparse[page][id&sparseSetPageMask]
+}
+
+// row returns the row+1 of the entity in the set, or zero if it's not in the set
+func (s *sparseSet) row(entity EntityID) uint32 {
+	slot := s.slot(entity.ID(), false)
+	if slot == nil {
+		return 0
+	}
+	row := *slot
+	if row == 0 || s.dense[row-1] != entity {
+		return 0
+	}
+	return row
+}
+
+func (s *sparseSet) has(entity EntityID) bool {
+	return s.row(entity) != 0
+}
+
+func (s *sparseSet) add(entity EntityID) {
+	if s.has(entity) {
+		return
+	}
+
+	slot := s.slot(entity.ID(), true)
+	row := uint32(len(s.dense))
+	s.dense = append(s.dense, entity)
+	s.storage.Expand(uint(row + 1))
+	*slot = row + 1
+}
+
+func (s *sparseSet) rem(entity EntityID) {
+	row := s.row(entity)
+	if row == 0 {
+		return
+	}
+
+	last := uint32(len(s.dense))
+	s.storage.MoveLast(uint(row-1), uint(last-1))
+	if row != last {
+		lastEntity := s.dense[last-1]
+		s.dense[row-1] = lastEntity
+		*s.slot(lastEntity.ID(), false) = row
+	}
+	s.dense = s.dense[:last-1]
+	*s.slot(entity.ID(), false) = 0
+}
+
+// sparseSet returns the set for the component, or nil if the component is not sparse.
+// The set is created the first time the component is used
+func (a *archetypeGraph) sparseSet(component ComponentID) *sparseSet {
+	if component < uint(len(a.sparse)) && a.sparse[component] != nil {
+		return a.sparse[component]
+	}
+
+	reg, ok := a.factory.GetByID(component)
+	if !ok || !reg.IsSparse() {
+		return nil
+	}
+
+	if component >= uint(len(a.sparse)) {
+		sparse := make([]*sparseSet, component+1)
+		copy(sparse, a.sparse)
+		a.sparse = sparse
+	}
+	a.sparse[component] = newSparseSet(reg.NewStorage())
+	return a.sparse[component]
+}
+
+// addSparse adds the entity to the sets of the sparse components in the list,
+// returning the components that must be stored in the archetype
+func (a *archetypeGraph) addSparse(entity EntityID, components []ComponentID) []ComponentID {
+	for i, id := range components {
+		if a.sparseSet(id) == nil {
+			continue
+		}
+
+		dense := append(make([]ComponentID, 0, len(components)), components[:i]...)
+		for _, id := range components[i:] {
+			if set := a.sparseSet(id); set != nil {
+				set.add(entity)
+			} else {
+				dense = append(dense, id)
+			}
+		}
+		return dense
+	}
+	return components
+}
+
+// remSparse removes the entity from all sparse sets
+func (a *archetypeGraph) remSparse(entity EntityID) {
+	for _, set := range a.sparse {
+		if set != nil {
+			set.rem(entity)
+		}
+	}
+}
+
+// sparseFilters removes the components stored in sparse sets from the mask, returning their sets.
+// Sparse components never used keep their bits, so no archetype matches the mask
+func (a *archetypeGraph) sparseFilters(mask DynamicMask) (DynamicMask, []*sparseSet) {
+	var filters []*sparseSet
+	for id, set := range a.sparse {
+		if set == nil || !mask.IsSet(uint64(id)) {
+			continue
+		}
+		if filters == nil {
+			mask = mask.Clone()
+		}
+		mask.Clear(uint64(id))
+		filters = append(filters, set)
+	}
+	return mask, filters
+}
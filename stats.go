@@ -0,0 +1,131 @@
+package ecs
+
+import (
+	"reflect"
+	"sort"
+)
+
+// EntityPoolStats is the runtime information of an EntityPool
+type EntityPoolStats struct {
+	Alive    uint // entities alive
+	Recycled uint // IDs in the recycle list, waiting to be reused
+	Reserved uint // IDs returned by Reserve, waiting to be materialized
+	Retired  uint // IDs with exhausted generation, never reused
+	Cap      uint // capacity of the pool in entities
+}
+
+// ArchetypeStats is the runtime information of an archetype
+type ArchetypeStats struct {
+	Mask        DynamicMask // components of the archetype, use DynamicMask.Mask() to convert it to Mask
+	Entities    uint        // entities stored in the archetype
+	Cap         uint        // capacity of the archetype in entities
+	Bytes       uint        // memory reserved by the columns, in Bytes
+	ScanBytes   uint        // memory that the GC has to scan for pointers, in Bytes
+	WastedBytes uint        // memory reserved by the columns but not used by the entities, in Bytes
+}
+
+// ComponentStats is the memory used by a component in all the archetypes and sparse sets
+type ComponentStats struct {
+	ID          ComponentID
+	Type        reflect.Type
+	Entities    uint // entities with the component
+	Bytes       uint // memory reserved for the component, in Bytes
+	ScanBytes   uint // memory that the GC has to scan for pointers, in Bytes
+	WastedBytes uint // memory reserved but not used by the entities, in Bytes
+}
+
+/*
+ArchetypeGraphStats is the runtime information of an ArchetypeGraph.
+
+Singleton components are not included, as they have only one value for all the entities.
+*/
+type ArchetypeGraphStats struct {
+	Entities    uint             // entities in the graph
+	Archetypes  []ArchetypeStats // stats for every archetype, in creation order
+	Components  []ComponentStats // stats for every component in use, sorted by ID
+	Bytes       uint             // memory reserved by all the columns, in Bytes
+	ScanBytes   uint             // memory that the GC has to scan for pointers, in Bytes
+	WastedBytes uint             // memory reserved by the columns but not used by the entities, in Bytes
+}
+
+// WorldStats is the runtime information of a World, used for debug overlays and memory budgets
+type WorldStats struct {
+	EntityPool EntityPoolStats
+	ArchetypeGraphStats
+}
+
+func (a *archetypeGraph) Stats() ArchetypeGraphStats {
+	var stats ArchetypeGraphStats
+	components := make(map[ComponentID]*ComponentStats)
+
+	addColumn := func(id ComponentID, column Storage, entities uint) (bytes, scanBytes, wasted uint) {
+		reg, ok := a.factory.GetByID(id)
+		if !ok || reg.kind == componentKindSingleton {
+			return 0, 0, 0
+		}
+
+		columnStats := column.Stats()
+		bytes = columnStats.ItemSize * columnStats.Cap
+		scanBytes = columnStats.ScanBytes
+		if columnStats.Cap > entities {
+			wasted = columnStats.ItemSize * (columnStats.Cap - entities)
+		}
+
+		comp, ok := components[id]
+		if !ok {
+			comp = &ComponentStats{ID: id, Type: reg.Type}
+			components[id] = comp
+		}
+		comp.Entities += entities
+		comp.Bytes += bytes
+		comp.ScanBytes += scanBytes
+		comp.WastedBytes += wasted
+
+		return bytes, scanBytes, wasted
+	}
+
+	stats.Archetypes = make([]ArchetypeStats, len(a.archetypes))
+	for i := range a.archetypes {
+		arch := &a.archetypes[i]
+		archStats := &stats.Archetypes[i]
+
+		archStats.Mask = arch.dynMask.Clone()
+		if !a.unconstrained {
+			archStats.Mask = arch.mask.Dynamic()
+		}
+		archStats.Entities = uint(len(arch.entities))
+		archStats.Cap = uint(cap(arch.entities))
+
+		for i, id := range arch.storage {
+			bytes, scanBytes, wasted := addColumn(id, arch.columns[i], archStats.Entities)
+			archStats.Bytes += bytes
+			archStats.ScanBytes += scanBytes
+			archStats.WastedBytes += wasted
+		}
+
+		stats.Entities += archStats.Entities
+		stats.Bytes += archStats.Bytes
+		stats.ScanBytes += archStats.ScanBytes
+		stats.WastedBytes += archStats.WastedBytes
+	}
+
+	for id, set := range a.sparse {
+		if set == nil {
+			continue
+		}
+		bytes, scanBytes, wasted := addColumn(ComponentID(id), set.storage, uint(len(set.dense)))
+		stats.Bytes += bytes
+		stats.ScanBytes += scanBytes
+		stats.WastedBytes += wasted
+	}
+
+	stats.Components = make([]ComponentStats, 0, len(components))
+	for _, comp := range components {
+		stats.Components = append(stats.Components, *comp)
+	}
+	sort.Slice(stats.Components, func(i, j int) bool {
+		return stats.Components[i].ID < stats.Components[j].ID
+	})
+
+	return stats
+}
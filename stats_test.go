@@ -0,0 +1,72 @@
+package ecs
+
+import (
+	"reflect"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorldStats(t *testing.T) {
+	const (
+		PositionCompID ComponentID = iota
+		NameCompID
+		PlayerCompID
+		HoveredCompID
+		ConfigCompID
+	)
+	type Position struct{ x, y float32 }
+	type Name struct{ value string }
+	type Player struct{}
+	type Hovered struct{ time float32 }
+	type Config struct{ gravity float32 }
+
+	world := NewWorld(0)
+	world.Register(NewComponentRegistry[Position](PositionCompID))
+	world.Register(NewComponentRegistry[Name](NameCompID))
+	world.Register(NewComponentRegistry[Player](PlayerCompID))
+	world.Register(NewSparseComponentRegistry[Hovered](HoveredCompID))
+	world.Register(NewSingletonComponentRegistry[Config](ConfigCompID))
+
+	for i := 0; i < 10; i++ {
+		world.NewEntity(PositionCompID, ConfigCompID)
+	}
+	e := world.NewEntity(PositionCompID, NameCompID, PlayerCompID, HoveredCompID)
+	world.RemEntity(world.NewEntity())
+
+	stats := world.Stats()
+	assert.Equal(t, EntityPoolStats{11, 1, 0, 0, 10240}, stats.EntityPool, "expected entity pool stats")
+	assert.EqualValues(t, 11, stats.Entities, "expected Entities to count the entities in the archetypes")
+	assert.Len(t, stats.Archetypes, 3, "expected stats for every archetype")
+
+	arch := stats.Archetypes[2]
+	assert.Equal(t, MakeComponentMask(PositionCompID, NameCompID, PlayerCompID), arch.Mask.Mask(), "expected archetype mask")
+	assert.EqualValues(t, 1, arch.Entities, "expected archetype entity count")
+	assert.EqualValues(t, 1, arch.Cap, "expected archetype capacity")
+	columnCap := uint(1) // the columns start empty and grow with the entities
+	assert.EqualValues(t, columnCap*uint(unsafe.Sizeof(Position{})+unsafe.Sizeof(Name{})), arch.Bytes, "expected archetype memory")
+	assert.EqualValues(t, columnCap*uint(unsafe.Sizeof(Name{})), arch.ScanBytes, "expected archetype memory scanned by the GC")
+	assert.EqualValues(t, arch.Bytes-uint(unsafe.Sizeof(Position{})+unsafe.Sizeof(Name{})), arch.WastedBytes, "expected archetype wasted memory")
+
+	assert.Len(t, stats.Components, 3, "expected stats for components with data, ignoring tags and singletons")
+	position := stats.Components[0]
+	assert.Equal(t, PositionCompID, position.ID, "expected components sorted by ID")
+	assert.Equal(t, reflect.TypeOf(Position{}), position.Type, "expected component type")
+	assert.EqualValues(t, 11, position.Entities, "expected component entity count")
+	assert.EqualValues(t, (16+columnCap)*uint(unsafe.Sizeof(Position{})), position.Bytes, "expected component memory")
+	assert.EqualValues(t, HoveredCompID, stats.Components[2].ID, "expected sparse components in the stats")
+	assert.EqualValues(t, 1, stats.Components[2].Entities, "expected sparse component entity count")
+
+	total := uint(0)
+	for _, comp := range stats.Components {
+		total += comp.Bytes
+	}
+	assert.Equal(t, total, stats.Bytes, "expected total memory to be the sum of the components")
+	assert.True(t, stats.WastedBytes < stats.Bytes, "expected wasted memory to be part of the total")
+
+	world.RemEntity(e)
+	stats = world.Stats()
+	assert.EqualValues(t, 0, stats.Archetypes[2].Entities, "expected stats to follow the world changes")
+	assert.EqualValues(t, stats.Archetypes[2].Bytes, stats.Archetypes[2].WastedBytes, "expected empty archetypes to waste all the memory")
+}
@@ -15,6 +15,12 @@ Set fill the memory block at Index with the contents of interface{}
 
 # Copy copies the contents of unsafe.Pointer to the index position
 
+CopyRange copies count items from the source Storage, starting at the from index, to the index position.
+Storages of the same type copy the whole range at once, others are copied item by item
+
+MoveLast copies the item at the last position to the index position and sets the last one to the
+zero value, used to fill the hole left by a removed item
+
 Shrink reduces the buffer size to the desired size.
 This function does nothing if the buffer size is smaller than the new size.
 
@@ -29,6 +35,8 @@ type Storage interface {
 	Get(uint) unsafe.Pointer
 	Set(uint, interface{}) bool
 	Copy(uint, unsafe.Pointer)
+	CopyRange(uint, Storage, uint, uint)
+	MoveLast(uint, uint)
 	Shrink(uint)
 	Expand(uint)
 	Reset()
@@ -37,15 +45,82 @@ type Storage interface {
 
 // StorageStats is the runtime information of a specific Storage
 type StorageStats struct {
-	Type     reflect.Type // type of the storage buffer
-	ItemSize uint         // size in Bytes for every instance of the item
-	Cap      uint         // current storage capacity in items
+	Type      reflect.Type // type of the storage buffer
+	ItemSize  uint         // size in Bytes for every instance of the item
+	Cap       uint         // current storage capacity in items
+	ScanBytes uint         // memory in Bytes that the GC has to scan for pointers
+}
+
+// typeFor returns the reflect.Type of T, including interface types, for which reflect.TypeOf returns nil
+func typeFor[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// scanBytes returns the memory that the GC has to scan for count items of the type
+func scanBytes(t reflect.Type, count uint) uint {
+	if isPointerFree(t) {
+		return 0
+	}
+	return uint(t.Size()) * count
+}
+
+// isPointerFree returns true if the type, and all the types it contains, don't have pointers,
+// making it safe to be stored in memory not managed by the Go runtime
+func isPointerFree(t reflect.Type) bool {
+	if t == nil {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return true
+	case reflect.Array:
+		return t.Len() == 0 || isPointerFree(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if !isPointerFree(t.Field(i).Type) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// copyRange copies the items one by one, for Storages without a faster way to copy from src.
+// The items are copied backwards when the ranges overlap in the same Storage
+func copyRange(dst Storage, index uint, src Storage, from, count uint) {
+	if dst == src && index > from {
+		for i := count; i > 0; i-- {
+			dst.Copy(index+i-1, src.Get(from+i-1))
+		}
+		return
+	}
+	for i := uint(0); i < count; i++ {
+		dst.Copy(index+i, src.Get(from+i))
+	}
 }
 
 const (
 	StorageBufferIncrementBy = 10000 // how much to increase the Storage when needed
 )
 
+// growCapacity returns the new capacity for a Storage that needs space for size items.
+// Small Storages double their capacity, so columns with a few entities stay small, and the
+// big ones grow by increment items
+func growCapacity(capacity, size, increment uint) uint {
+	step := capacity
+	if step > increment {
+		step = increment
+	}
+	if capacity+step < size {
+		return size
+	}
+	return capacity + step
+}
+
 type storage[T any] struct {
 	increment uint
 	buffer    []T
@@ -59,12 +134,9 @@ func NewStorage[T any](initialLen, increment uint) Storage {
 		increment = StorageBufferIncrementBy
 	}
 
-	buffer := make([]T, initialLen)
-	return &storage[T]{
-		increment,
-		buffer,
-		unsafe.Pointer(&buffer[0]),
-	}
+	s := &storage[T]{increment: increment}
+	s.Expand(initialLen)
+	return s
 }
 
 func (s *storage[T]) Get(index uint) unsafe.Pointer {
@@ -90,10 +162,28 @@ func (s *storage[T]) Copy(index uint, ptr unsafe.Pointer) {
 	*to = *from
 }
 
+func (s *storage[T]) CopyRange(index uint, src Storage, from, count uint) {
+	if other, ok := src.(*storage[T]); ok {
+		copy(s.buffer[index:index+count], other.buffer[from:from+count])
+		return
+	}
+	copyRange(s, index, src, from, count)
+}
+
+func (s *storage[T]) MoveLast(index, last uint) {
+	var zero T
+	s.buffer[index] = s.buffer[last]
+	s.buffer[last] = zero
+}
+
 func (s *storage[T]) Shrink(to uint) {
 	if to < uint(len(s.buffer)) {
 		prev := s.buffer[0:int(to)]
 		s.buffer = make([]T, int(to))
+		s.bufferPtr = unsafe.Pointer(nil)
+		if to > 0 {
+			s.bufferPtr = unsafe.Pointer(&s.buffer[0])
+		}
 		copy(s.buffer, prev)
 	}
 }
@@ -108,20 +198,20 @@ func (s *storage[T]) Reset() {
 }
 
 func (s storage[T]) Stats() StorageStats {
-	var t T
-	typeOf := reflect.TypeOf(t)
+	typeOf := typeFor[T]()
 
 	return StorageStats{
 		typeOf,
 		uint(typeOf.Size()),
 		uint(len(s.buffer)),
+		scanBytes(typeOf, uint(len(s.buffer))),
 	}
 }
 
 func (s *storage[T]) ensureCap(size uint) {
-	if size >= uint(cap(s.buffer)) {
+	if size > uint(cap(s.buffer)) {
 		prevBuffer := s.buffer
-		s.buffer = make([]T, int(size+s.increment))
+		s.buffer = make([]T, int(growCapacity(uint(cap(s.buffer)), size, s.increment)))
 		s.bufferPtr = unsafe.Pointer(&s.buffer[0])
 		copy(s.buffer, prevBuffer)
 	}
@@ -0,0 +1,141 @@
+package ecs
+
+import (
+	"math/bits"
+	"unsafe"
+)
+
+const (
+	StorageChunkSize = 16 * 1024 // default size in Bytes for every chunk in the chunked Storage
+)
+
+type storageChunked[T any] struct {
+	chunks [][]T
+	shift  uint
+	mask   uint
+}
+
+/*
+NewStorageChunked returns an implementation for Storage that allocates memory in fixed size chunks.
+
+Every chunk has space for a power of two number of items that fits in chunkSize Bytes, or one item
+if it's bigger than chunkSize. When the Storage needs to grow, new chunks are allocated and the
+existing items are never copied, so the pointers for them remain valid.
+
+If chunkSize is zero, StorageChunkSize will be used.
+
+To use it for a component, replace the NewStorage in the ComponentRegistry:
+
+	reg := ecs.NewComponentRegistry[Particle](ParticleComponentID)
+	reg.NewStorage = func() ecs.Storage {
+		return ecs.NewStorageChunked[Particle](0, 0)
+	}
+*/
+func NewStorageChunked[T any](initialLen, chunkSize uint) Storage {
+	if chunkSize == 0 {
+		chunkSize = StorageChunkSize
+	}
+
+	var t T
+	itemsPerChunk := chunkSize
+	if size := uint(unsafe.Sizeof(t)); size > 0 {
+		itemsPerChunk = chunkSize / size
+	}
+	shift := uint(0)
+	if itemsPerChunk > 1 {
+		shift = uint(bits.Len(itemsPerChunk)) - 1
+	}
+
+	s := &storageChunked[T]{
+		shift: shift,
+		mask:  (1 << shift) - 1,
+	}
+	s.Expand(initialLen)
+	return s
+}
+
+func (s *storageChunked[T]) Get(index uint) unsafe.Pointer {
+	return unsafe.Pointer(s.at(index))
+}
+
+func (s *storageChunked[T]) Set(index uint, value interface{}) bool {
+	if index >= s.capacity() {
+		return false
+	}
+	v, ok := value.(*T)
+	if ok {
+		ptr := (*T)(s.Get(index))
+		*ptr = *v
+	}
+	return ok
+}
+
+func (s *storageChunked[T]) Copy(index uint, ptr unsafe.Pointer) {
+	to := (*T)(s.Get(index))
+	from := (*T)(ptr)
+	*to = *from
+}
+
+func (s *storageChunked[T]) CopyRange(index uint, src Storage, from, count uint) {
+	other, ok := src.(*storageChunked[T])
+	if !ok {
+		copyRange(s, index, src, from, count)
+		return
+	}
+	if s == other && index > from {
+		for i := count; i > 0; i-- {
+			*s.at(index + i - 1) = *other.at(from + i - 1)
+		}
+		return
+	}
+	for i := uint(0); i < count; i++ {
+		*s.at(index + i) = *other.at(from + i)
+	}
+}
+
+func (s *storageChunked[T]) MoveLast(index, last uint) {
+	var zero T
+	*s.at(index) = *s.at(last)
+	*s.at(last) = zero
+}
+
+// Shrink releases the chunks that are not needed to keep the first to items
+func (s *storageChunked[T]) Shrink(to uint) {
+	count := int((to + s.mask) >> s.shift)
+	if count < len(s.chunks) {
+		for i := count; i < len(s.chunks); i++ {
+			s.chunks[i] = nil
+		}
+		s.chunks = s.chunks[:count]
+	}
+}
+
+// Expand allocates new chunks until the Storage have space for to items
+func (s *storageChunked[T]) Expand(to uint) {
+	for s.capacity() < to {
+		s.chunks = append(s.chunks, make([]T, s.mask+1))
+	}
+}
+
+func (s *storageChunked[T]) Reset() {
+	s.chunks = nil
+}
+
+func (s storageChunked[T]) Stats() StorageStats {
+	typeOf := typeFor[T]()
+
+	return StorageStats{
+		typeOf,
+		uint(typeOf.Size()),
+		s.capacity(),
+		scanBytes(typeOf, s.capacity()),
+	}
+}
+
+func (s *storageChunked[T]) capacity() uint {
+	return uint(len(s.chunks)) << s.shift
+}
+
+func (s *storageChunked[T]) at(index uint) *T {
+	return &s.chunks[index>>s.shift][index&s.mask]
+}
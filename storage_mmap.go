@@ -0,0 +1,73 @@
+package ecs
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+)
+
+/*
+StorageMmap is a Storage for pointer-free components backed by a memory mapped file.
+
+The contents of the Storage are the contents of the file, so saving is a Flush and loading is
+creating the Storage again with the same file, that keeps the capacity and the items stored in it.
+
+# Flush writes the changes in memory to the file
+
+Close unmaps the memory and closes the file. The Storage and every pointer returned by Get are invalid after Close
+*/
+type StorageMmap interface {
+	Storage
+	Flush() error
+	Close() error
+}
+
+var (
+	// ErrStorageHasPointers is returned when the type can't be stored outside the memory managed by the runtime
+	ErrStorageHasPointers = errors.New("ecs: type with pointers can't be stored in memory mapped files")
+	// ErrStorageZeroSize is returned when the type don't have data to be stored
+	ErrStorageZeroSize = errors.New("ecs: zero sized type can't be stored in memory mapped files")
+	// ErrStorageMmapUnsupported is returned in the platforms without memory mapped files
+	ErrStorageMmapUnsupported = errors.New("ecs: memory mapped files are not supported in this platform")
+)
+
+/*
+NewMmapComponentRegistry[T] returns a ComponentRegistry definition for the type T and id that stores
+the columns in memory mapped files in dir, using NewStorageMmap. Every archetype with the component
+has its own file, named by the component id and the order the columns were created, so a world that
+creates the same archetypes in the same order loads the items stored by the previous one.
+
+The files are closed, keeping their contents, when the archetypes are removed from the world.
+Returns ErrStorageHasPointers or ErrStorageZeroSize if T can't be stored in files, and
+ErrStorageMmapUnsupported in the platforms without memory mapped files. As the Storage interface
+don't return errors, NewStorage panics if the file can't be opened.
+*/
+func NewMmapComponentRegistry[T any](id ComponentID, dir string) (ComponentRegistry, error) {
+	typeOf := typeFor[T]()
+	if !isPointerFree(typeOf) {
+		return ComponentRegistry{}, ErrStorageHasPointers
+	}
+	if typeOf.Size() == 0 {
+		return ComponentRegistry{}, ErrStorageZeroSize
+	}
+	if !mmapSupported {
+		return ComponentRegistry{}, ErrStorageMmapUnsupported
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return ComponentRegistry{}, err
+	}
+
+	var columns uint64
+	reg := NewComponentRegistry[T](id)
+	reg.NewStorage = func() Storage {
+		column := atomic.AddUint64(&columns, 1)
+		s, err := NewStorageMmap(new(T), filepath.Join(dir, fmt.Sprintf("component_%d_%d.bin", id, column)), 0, 0)
+		if err != nil {
+			panic(err)
+		}
+		return s
+	}
+	return reg, nil
+}
@@ -0,0 +1,11 @@
+//go:build !linux && !darwin
+
+package ecs
+
+// mmapSupported is true in the platforms with NewStorageMmap
+const mmapSupported = false
+
+// NewStorageMmap returns ErrStorageMmapUnsupported, as this platform don't have memory mapped files
+func NewStorageMmap(ref interface{}, path string, initialLen, increment uint) (StorageMmap, error) {
+	return nil, ErrStorageMmapUnsupported
+}
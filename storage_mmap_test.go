@@ -0,0 +1,123 @@
+//go:build linux || darwin
+
+package ecs
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStorageMmap(t *testing.T) {
+	type vec3 struct{ x, y, z float32 }
+	type named struct{ name string }
+	type tag struct{}
+
+	path := filepath.Join(t.TempDir(), "vec3.bin")
+
+	_, err := NewStorageMmap(named{}, path, 10, 0)
+	assert.ErrorIs(t, err, ErrStorageHasPointers, "expected types with pointers to be rejected")
+	_, err = NewStorageMmap(tag{}, path, 10, 0)
+	assert.ErrorIs(t, err, ErrStorageZeroSize, "expected zero sized types to be rejected")
+	_, err = NewStorageMmap(vec3{}, filepath.Join(path, "invalid"), 10, 0)
+	assert.Error(t, err, "expected invalid paths to return error")
+
+	s, err := NewStorageMmap(vec3{}, path, 10, 16)
+	assert.NoError(t, err, "expected NewStorageMmap to create the file")
+	assert.EqualValues(t, 10, s.Stats().Cap, "expected initial capacity to be initialLen")
+
+	for i := 0; i < 10; i++ {
+		assert.True(t, s.Set(uint(i), &vec3{float32(i), 1, 2}), "expected Set() to return true for valid index")
+	}
+	assert.False(t, s.Set(10, &vec3{}), "expected Set() to return false for invalid index")
+	assert.False(t, s.Set(0, &named{}), "expected Set() to return false for invalid types")
+
+	held := (*vec3)(s.Get(9))
+	s.Expand(20)
+	assert.EqualValues(t, 32, s.Stats().Cap, "expected Expand to grow by increment")
+	held.x = 90
+	assert.Equal(t, vec3{90, 1, 2}, *(*vec3)(s.Get(9)), "expected pointers to stay valid after Expand")
+	s.Copy(20, s.Get(5))
+	assert.Equal(t, vec3{5, 1, 2}, *(*vec3)(s.Get(20)), "expected Copy to copy the values")
+
+	s.Shrink(21)
+	assert.NoError(t, s.Flush(), "expected Flush to write the file")
+	assert.NoError(t, s.Close(), "expected Close to release the file")
+
+	s, err = NewStorageMmap(&vec3{}, path, 0, 0)
+	assert.NoError(t, err, "expected NewStorageMmap to open the file")
+	assert.EqualValues(t, 21, s.Stats().Cap, "expected capacity to be loaded from the file")
+	for i := 0; i < 10; i++ {
+		if i != 9 {
+			assert.Equal(t, vec3{float32(i), 1, 2}, *(*vec3)(s.Get(uint(i))), "expected values to be loaded from the file")
+		}
+	}
+	assert.Equal(t, vec3{90, 1, 2}, *(*vec3)(s.Get(9)), "expected values changed by old pointers to be in the file")
+	assert.Equal(t, vec3{5, 1, 2}, *(*vec3)(s.Get(20)), "expected values to be loaded from the file")
+
+	s.CopyRange(0, s, 1, 2)
+	assert.Equal(t, vec3{2, 1, 2}, *(*vec3)(s.Get(1)), "expected CopyRange to copy the items")
+	s.CopyRange(10, NewStorage[vec3](2, 0), 0, 2)
+	assert.Equal(t, vec3{}, *(*vec3)(s.Get(11)), "expected CopyRange to copy from other storages")
+	s.MoveLast(0, 20)
+	assert.Equal(t, vec3{5, 1, 2}, *(*vec3)(s.Get(0)), "expected MoveLast to move the last item into the hole")
+	assert.Equal(t, vec3{}, *(*vec3)(s.Get(20)), "expected MoveLast to zero the last item")
+
+	s.Reset()
+	assert.EqualValues(t, 0, s.Stats().Cap, "expected Reset to unmap the items")
+	assert.NoError(t, s.Flush(), "expected Flush to work for empty storages")
+	assert.NoError(t, s.Close(), "expected Close to work after Reset")
+	assert.Panics(t, func() { s.Expand(1) }, "expected the Storage to be unusable after Close")
+	assert.NoError(t, s.Close(), "expected Close to work after Close")
+
+	s, err = NewStorageMmap(vec3{}, path, 0, 0)
+	assert.NoError(t, err, "expected NewStorageMmap to open the file")
+	assert.EqualValues(t, 21, s.Stats().Cap, "expected Reset to keep the file")
+	assert.Equal(t, vec3{3, 1, 2}, *(*vec3)(s.Get(3)), "expected Reset to keep the items in the file")
+
+	s.Reset()
+	s.Expand(4)
+	assert.True(t, s.Stats().Cap >= 4, "expected the Storage to be usable after Reset")
+	assert.Equal(t, vec3{}, *(*vec3)(s.Get(3)), "expected the items discarded by Reset to be zeroed")
+	assert.True(t, s.Set(3, &vec3{7, 8, 9}), "expected Set to work after Reset")
+	assert.Equal(t, vec3{7, 8, 9}, *(*vec3)(s.Get(3)), "expected Set to work after Reset")
+	assert.NoError(t, s.Close(), "expected Close to release the file")
+}
+
+func TestStorageMmapWorld(t *testing.T) {
+	const PositionCompID ComponentID = 0
+	type Position struct{ x, y float32 }
+
+	dir := t.TempDir()
+
+	_, err := NewMmapComponentRegistry[struct{ name string }](PositionCompID, dir)
+	assert.ErrorIs(t, err, ErrStorageHasPointers, "expected types with pointers to be rejected")
+	reg, err := NewMmapComponentRegistry[Position](PositionCompID, dir)
+	assert.NoError(t, err, "expected NewMmapComponentRegistry to accept pointer-free types")
+
+	world := NewWorld(0)
+	world.Register(reg)
+
+	entities := make([]EntityID, 100)
+	for i := range entities {
+		entities[i] = world.NewEntity(PositionCompID)
+		world.SetComponent(entities[i], PositionCompID, &Position{float32(i), float32(i)})
+	}
+	for i, e := range entities {
+		assert.Equal(t, Position{float32(i), float32(i)}, *(*Position)(world.Component(e, PositionCompID)), "expected values to be stored in the file")
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*.bin"))
+	assert.Len(t, files, 1, "expected one file for every archetype")
+
+	for _, e := range entities {
+		world.RemEntity(e)
+	}
+	world.RemEmptyArchetypes()
+
+	s, err := NewStorageMmap(Position{}, files[0], 0, 0)
+	assert.NoError(t, err, "expected the file to be closed by RemEmptyArchetypes")
+	assert.Equal(t, Position{}, *(*Position)(s.Get(0)), "expected the removed entities to be zeroed")
+	assert.True(t, s.Stats().Cap >= uint(len(entities)), "expected RemEmptyArchetypes to keep the file")
+	assert.NoError(t, s.Close(), "expected Close to release the file")
+}
@@ -0,0 +1,281 @@
+//go:build linux || darwin
+
+package ecs
+
+import (
+	"os"
+	"reflect"
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+// mmapSupported is true in the platforms with NewStorageMmap
+const mmapSupported = true
+
+type storageMmap struct {
+	file      *os.File
+	data      []byte
+	base      unsafe.Pointer
+	typeOf    reflect.Type
+	itemSize  uintptr
+	increment uint
+	retired   [][]byte // mappings replaced by Expand, kept until Shrink, Reset or Close
+	discarded bool     // the file keeps the items discarded by Reset until the Storage grows again
+}
+
+/*
+NewStorageMmap creates a Storage for the type of ref, mapping its items to the file in path.
+
+If the file exists, its contents are loaded and the capacity of the Storage is the number of items
+in the file, but never less than initialLen. The type must be pointer-free, as the Go runtime don't
+know about the memory in the file. If increment is zero, StorageBufferIncrementBy will be used.
+
+When the Storage grows, the file is mapped again and the old mapping is kept until the next Shrink,
+Reset or Close, so the pointers returned by Get remain valid until then, as both mappings share the
+same file. Reset unmaps the memory, keeping the contents on disk until the Storage grows again,
+when it starts with zeroed items. Close releases the file, and it's called by the finalizer of the
+Storage if it's not closed before.
+
+To use it for the components, see NewMmapComponentRegistry[T].
+*/
+func NewStorageMmap(ref interface{}, path string, initialLen, increment uint) (StorageMmap, error) {
+	if increment == 0 {
+		increment = StorageBufferIncrementBy
+	}
+
+	tp := reflect.Indirect(reflect.ValueOf(ref)).Type()
+	if !isPointerFree(tp) {
+		return nil, ErrStorageHasPointers
+	}
+	if tp.Size() == 0 {
+		return nil, ErrStorageZeroSize
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	s := &storageMmap{
+		file:      file,
+		typeOf:    tp,
+		itemSize:  tp.Size(),
+		increment: increment,
+	}
+
+	capacity := uint(uintptr(info.Size()) / s.itemSize)
+	if capacity < initialLen {
+		capacity = initialLen
+	}
+	if err := s.remap(capacity); err != nil {
+		file.Close()
+		return nil, err
+	}
+	runtime.SetFinalizer(s, func(s *storageMmap) {
+		s.Close()
+	})
+
+	return s, nil
+}
+
+func (s *storageMmap) Get(index uint) unsafe.Pointer {
+	return unsafe.Add(s.base, uintptr(index)*s.itemSize)
+}
+
+func (s *storageMmap) Set(index uint, value interface{}) bool {
+	rValue := reflect.ValueOf(value)
+	if index >= s.capacity() || rValue.Kind() != reflect.Pointer || rValue.Type().Elem() != s.typeOf {
+		return false
+	}
+	s.Copy(index, rValue.UnsafePointer())
+	return true
+}
+
+func (s *storageMmap) Copy(index uint, ptr unsafe.Pointer) {
+	offset := uintptr(index) * s.itemSize
+	copy(s.data[offset:offset+s.itemSize], unsafe.Slice((*byte)(ptr), s.itemSize))
+}
+
+func (s *storageMmap) CopyRange(index uint, src Storage, from, count uint) {
+	if other, ok := src.(*storageMmap); ok && other.typeOf == s.typeOf {
+		copy(s.items(index, count), other.items(from, count))
+		return
+	}
+	copyRange(s, index, src, from, count)
+}
+
+func (s *storageMmap) MoveLast(index, last uint) {
+	if index != last {
+		s.CopyRange(index, s, last, 1)
+	}
+	item := s.items(last, 1)
+	for i := range item {
+		item[i] = 0
+	}
+}
+
+func (s *storageMmap) Shrink(to uint) {
+	if to < s.capacity() {
+		s.mustRemap(to)
+	}
+	if err := s.release(); err != nil {
+		panic(err)
+	}
+}
+
+// Expand grows the file by at least its capacity, so the number of old mappings kept is bounded
+func (s *storageMmap) Expand(to uint) {
+	if to > s.capacity() {
+		if to < s.capacity()*2 {
+			to = s.capacity() * 2
+		}
+		if err := s.grow(s.increment * ((to + s.increment) / s.increment)); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// Reset unmaps the memory, keeping the items on disk until the next Expand
+func (s *storageMmap) Reset() {
+	if err := s.unmap(); err != nil {
+		panic(err)
+	}
+	s.discarded = true
+}
+
+func (s *storageMmap) Stats() StorageStats {
+	return StorageStats{
+		s.typeOf,
+		uint(s.itemSize),
+		s.capacity(),
+		0,
+	}
+}
+
+func (s *storageMmap) Flush() error {
+	if len(s.data) == 0 {
+		return nil
+	}
+	_, _, errno := syscall.Syscall(syscall.SYS_MSYNC, uintptr(s.base), uintptr(len(s.data)), syscall.MS_SYNC)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+func (s *storageMmap) Close() error {
+	if err := s.unmap(); err != nil {
+		return err
+	}
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// items returns the bytes of count items starting at index
+func (s *storageMmap) items(index, count uint) []byte {
+	offset := uintptr(index) * s.itemSize
+	return s.data[offset : offset+uintptr(count)*s.itemSize]
+}
+
+func (s *storageMmap) capacity() uint {
+	return uint(uintptr(len(s.data)) / s.itemSize)
+}
+
+// mustRemap panics if the file can't be resized, as the Storage interface don't return errors
+func (s *storageMmap) mustRemap(capacity uint) {
+	if err := s.remap(capacity); err != nil {
+		panic(err)
+	}
+}
+
+// remap resizes the file to have space for capacity items and maps it again, releasing the old mappings
+func (s *storageMmap) remap(capacity uint) error {
+	if err := s.unmap(); err != nil {
+		return err
+	}
+	return s.mmap(capacity)
+}
+
+// grow resizes the file to have space for capacity items and maps it again,
+// keeping the old mapping valid until the next release
+func (s *storageMmap) grow(capacity uint) error {
+	data := s.data
+	if err := s.mmap(capacity); err != nil {
+		return err
+	}
+	if len(data) > 0 {
+		s.retired = append(s.retired, data)
+	}
+	return nil
+}
+
+// mmap resizes the file and maps its capacity items, replacing the actual mapping without releasing it
+func (s *storageMmap) mmap(capacity uint) error {
+	if s.file == nil {
+		return os.ErrClosed
+	}
+
+	if s.discarded && capacity > 0 {
+		// the items discarded by Reset must not appear again
+		if err := s.file.Truncate(0); err != nil {
+			return err
+		}
+		s.discarded = false
+	}
+
+	size := int(uintptr(capacity) * s.itemSize)
+	if err := s.file.Truncate(int64(size)); err != nil {
+		return err
+	}
+	if size == 0 {
+		s.data = nil
+		s.base = nil
+		return nil
+	}
+
+	data, err := syscall.Mmap(int(s.file.Fd()), 0, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+	if err != nil {
+		return err
+	}
+	s.data = data
+	s.base = unsafe.Pointer(&data[0])
+	return nil
+}
+
+// unmap releases the actual and the old mappings
+func (s *storageMmap) unmap() error {
+	if err := s.release(); err != nil {
+		return err
+	}
+	if len(s.data) == 0 {
+		return nil
+	}
+	if err := syscall.Munmap(s.data); err != nil {
+		return err
+	}
+	s.data = nil
+	s.base = nil
+	return nil
+}
+
+// release unmaps the mappings replaced by grow
+func (s *storageMmap) release() error {
+	for len(s.retired) > 0 {
+		last := len(s.retired) - 1
+		if err := syscall.Munmap(s.retired[last]); err != nil {
+			return err
+		}
+		s.retired = s.retired[:last]
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package ecs
+
+import (
+	"runtime"
+	"unsafe"
+)
+
+type storageNoScan[T any] struct {
+	increment uint
+	data      []byte
+	bufferPtr unsafe.Pointer
+	capacity  uint
+	retired   [][]byte // buffers replaced by Expand, kept mapped until Shrink or Reset
+}
+
+/*
+NewStorageNoScan returns an implementation for Storage that allocates the items outside the Go heap,
+so the memory is never scanned by the GC and don't count for the GC pacing.
+
+T must be pointer-free, as the GC don't know about this memory, or this function panics.
+The memory is released when the Storage is collected by the GC.
+
+When the Storage grows, the items are copied to a new buffer and the old one is kept mapped until
+the next Shrink or Reset, so the pointers returned by Get can still be read, but the changes made
+through them are lost, like in the Storage returned by NewStorage. Shrink and Reset release the old
+buffers, and the pointers returned before them must not be used anymore. To keep the memory of the
+old buffers bounded, the Storage grows by at least its capacity.
+If increment is zero, StorageBufferIncrementBy will be used
+*/
+func NewStorageNoScan[T any](initialLen, increment uint) Storage {
+	if !isPointerFree(typeFor[T]()) {
+		panic("NewStorageNoScan can't store types with pointers")
+	}
+	if increment == 0 {
+		increment = StorageBufferIncrementBy
+	}
+
+	s := &storageNoScan[T]{increment: increment}
+	s.resize(initialLen)
+	runtime.SetFinalizer(s, func(s *storageNoScan[T]) {
+		s.release()
+		freeNoScan(s.data)
+	})
+	return s
+}
+
+func (s *storageNoScan[T]) Get(index uint) unsafe.Pointer {
+	var t T
+	return unsafe.Add(s.bufferPtr, unsafe.Sizeof(t)*uintptr(index))
+}
+
+func (s *storageNoScan[T]) Set(index uint, value interface{}) bool {
+	if index >= s.capacity {
+		return false
+	}
+	v, ok := value.(*T)
+	if ok {
+		ptr := (*T)(s.Get(index))
+		*ptr = *v
+	}
+	return ok
+}
+
+func (s *storageNoScan[T]) Copy(index uint, ptr unsafe.Pointer) {
+	to := (*T)(s.Get(index))
+	from := (*T)(ptr)
+	*to = *from
+}
+
+func (s *storageNoScan[T]) CopyRange(index uint, src Storage, from, count uint) {
+	if other, ok := src.(*storageNoScan[T]); ok {
+		copy(s.items()[index:index+count], other.items()[from:from+count])
+		return
+	}
+	copyRange(s, index, src, from, count)
+}
+
+func (s *storageNoScan[T]) MoveLast(index, last uint) {
+	var zero T
+	items := s.items()
+	items[index] = items[last]
+	items[last] = zero
+}
+
+func (s *storageNoScan[T]) Shrink(to uint) {
+	if to < s.capacity {
+		s.resize(to)
+	}
+	s.release()
+}
+
+func (s *storageNoScan[T]) Expand(to uint) {
+	if to > s.capacity {
+		capacity := growCapacity(s.capacity, to, s.increment)
+		if capacity < s.capacity*2 {
+			capacity = s.capacity * 2
+		}
+		s.resize(capacity)
+	}
+}
+
+func (s *storageNoScan[T]) Reset() {
+	s.resize(0)
+	s.release()
+}
+
+func (s *storageNoScan[T]) Stats() StorageStats {
+	typeOf := typeFor[T]()
+
+	return StorageStats{
+		typeOf,
+		uint(typeOf.Size()),
+		s.capacity,
+		0,
+	}
+}
+
+// items returns the buffer as a slice of T
+func (s *storageNoScan[T]) items() []T {
+	return unsafe.Slice((*T)(s.bufferPtr), s.capacity)
+}
+
+// resize moves the items to a new buffer with space for capacity items, retiring the old one
+func (s *storageNoScan[T]) resize(capacity uint) {
+	var t T
+	data := allocNoScan(unsafe.Sizeof(t) * uintptr(capacity))
+	copy(data, s.data)
+	if len(s.data) > 0 {
+		s.retired = append(s.retired, s.data)
+	}
+
+	s.data = data
+	s.capacity = capacity
+	s.bufferPtr = unsafe.Pointer(&tagValue)
+	if len(data) > 0 {
+		s.bufferPtr = unsafe.Pointer(&data[0])
+	}
+}
+
+// release frees the buffers retired by resize
+func (s *storageNoScan[T]) release() {
+	for i, data := range s.retired {
+		freeNoScan(data)
+		s.retired[i] = nil
+	}
+	s.retired = s.retired[:0]
+}
@@ -74,6 +74,19 @@ func (s *storageReflect) Copy(index uint, value unsafe.Pointer) {
 	copy(dstSlice, srcSlice)
 }
 
+func (s *storageReflect) CopyRange(index uint, src Storage, from, count uint) {
+	if other, ok := src.(*storageReflect); ok && other.typeOf == s.typeOf {
+		reflect.Copy(s.buffer.Slice(int(index), int(index+count)), other.buffer.Slice(int(from), int(from+count)))
+		return
+	}
+	copyRange(s, index, src, from, count)
+}
+
+func (s *storageReflect) MoveLast(index, last uint) {
+	s.buffer.Index(int(index)).Set(s.buffer.Index(int(last)))
+	s.buffer.Index(int(last)).Set(reflect.Zero(s.typeOf))
+}
+
 func (s *storageReflect) Shrink(cap uint) {
 	if uint(s.buffer.Cap()) > cap {
 		old := s.buffer
@@ -102,5 +115,6 @@ func (s storageReflect) Stats() StorageStats {
 		s.typeOf,
 		uint(s.typeOf.Size()),
 		uint(s.buffer.Cap()),
+		scanBytes(s.typeOf, uint(s.buffer.Cap())),
 	}
 }
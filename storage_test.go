@@ -56,9 +56,11 @@ func TestStorageAllocation(t *testing.T) {
 
 	genericStorage := NewStorage[vec3](1, 5)
 	reflectStorage := NewStorageReflect(vec3{}, 1, 5)
+	noScanStorage := NewStorageNoScan[vec3](1, 5)
 
 	testStorageAllocation(t, genericStorage)
 	testStorageAllocation(t, reflectStorage)
+	testStorageAllocation(t, noScanStorage)
 }
 
 func TestStorageRemove(t *testing.T) {
@@ -79,7 +81,138 @@ func TestStorageRemove(t *testing.T) {
 
 	genericStorage := NewStorage[vec3](10, 0)
 	reflectStorage := NewStorageReflect(vec3{}, 10, 0)
+	chunkedStorage := NewStorageChunked[vec3](10, 16)
+	noScanStorage := NewStorageNoScan[vec3](10, 0)
 
 	testStorageRemove(t, genericStorage)
 	testStorageRemove(t, reflectStorage)
+	testStorageRemove(t, chunkedStorage)
+	testStorageRemove(t, noScanStorage)
+}
+
+func TestStorageBulk(t *testing.T) {
+	type vec3 struct{ x, y, z float32 }
+
+	values := func(s Storage, count int) []vec3 {
+		result := make([]vec3, count)
+		for i := range result {
+			result[i] = *(*vec3)(s.Get(uint(i)))
+		}
+		return result
+	}
+
+	testStorageBulk := func(t *testing.T, s, other Storage) {
+		s.Expand(8)
+		other.Expand(8)
+		for i := 0; i < 8; i++ {
+			f := float32(i)
+			s.Set(uint(i), &vec3{f, f, f})
+			other.Set(uint(i), &vec3{-f, -f, -f})
+		}
+
+		s.CopyRange(1, other, 5, 2)
+		assert.Equal(t, []vec3{{0, 0, 0}, {-5, -5, -5}, {-6, -6, -6}, {3, 3, 3}}, values(s, 4), "expected CopyRange to copy from the other storage")
+
+		s.CopyRange(2, s, 1, 3)
+		assert.Equal(t, []vec3{{0, 0, 0}, {-5, -5, -5}, {-5, -5, -5}, {-6, -6, -6}, {3, 3, 3}}, values(s, 5), "expected CopyRange to handle overlapping ranges")
+
+		s.MoveLast(1, 7)
+		assert.Equal(t, vec3{7, 7, 7}, *(*vec3)(s.Get(1)), "expected MoveLast to move the last item into the hole")
+		assert.Equal(t, vec3{}, *(*vec3)(s.Get(7)), "expected MoveLast to zero the last item")
+		s.MoveLast(6, 6)
+		assert.Equal(t, vec3{}, *(*vec3)(s.Get(6)), "expected MoveLast to zero the item when it's the last one")
+	}
+
+	testStorageBulk(t, NewStorage[vec3](1, 0), NewStorage[vec3](1, 0))
+	testStorageBulk(t, NewStorageReflect(vec3{}, 1, 0), NewStorageReflect(vec3{}, 1, 0))
+	testStorageBulk(t, NewStorageChunked[vec3](1, 16), NewStorageChunked[vec3](1, 16))
+	testStorageBulk(t, NewStorageNoScan[vec3](1, 0), NewStorageNoScan[vec3](1, 0))
+	testStorageBulk(t, NewStorage[vec3](1, 0), NewStorageReflect(vec3{}, 1, 0))
+	testStorageBulk(t, NewStorageChunked[vec3](1, 16), NewStorage[vec3](1, 0))
+}
+
+func TestStorageNoScan(t *testing.T) {
+	type vec3 struct{ x, y, z float32 }
+	type named struct{ name string }
+	type tag struct{}
+
+	assert.Panics(t, func() {
+		NewStorageNoScan[named](1, 1)
+	}, "NewStorageNoScan should panic for types with pointers")
+
+	empty := NewStorageNoScan[tag](10, 0)
+	assert.False(t, empty.Get(5) == unsafe.Pointer(nil), "expected valid pointer for zero sized types")
+
+	assert.Zero(t, NewStorageNoScan[vec3](100, 0).Stats().ScanBytes, "expected pointer-free storages to not be scanned")
+	assert.Zero(t, NewStorage[vec3](100, 0).Stats().ScanBytes, "expected pointer-free storages to not be scanned")
+	assert.EqualValues(t, 100*unsafe.Sizeof(named{}), NewStorage[named](100, 0).Stats().ScanBytes, "expected storages with pointers to be scanned")
+	assert.EqualValues(t, 100*unsafe.Sizeof(named{}), NewStorageReflect(named{}, 100, 0).Stats().ScanBytes, "expected storages with pointers to be scanned")
+	assert.False(t, isPointerFree(nil), "expected nil types to not be pointer-free")
+
+	grow := NewStorageNoScan[vec3](1, 1)
+	held := (*vec3)(grow.Get(0))
+	*held = vec3{1, 2, 3}
+	grow.Expand(100)
+	assert.Equal(t, vec3{1, 2, 3}, *held, "expected old buffers to stay mapped after Expand")
+	assert.Equal(t, vec3{1, 2, 3}, *(*vec3)(grow.Get(0)), "expected Expand to keep the items")
+	grow.Shrink(10)
+	assert.Empty(t, grow.(*storageNoScan[vec3]).retired, "expected Shrink to release the old buffers")
+	assert.Equal(t, vec3{1, 2, 3}, *(*vec3)(grow.Get(0)), "expected Shrink to keep the items")
+}
+
+func TestStorageChunked(t *testing.T) {
+	type vec3 struct{ x, y, z float32 }
+	type tag struct{}
+
+	s := NewStorageChunked[vec3](1, 64)
+	stats := s.Stats()
+	assert.EqualValues(t, 4, stats.Cap, "expected chunks with a power of two number of items (got %d)", stats.Cap)
+	assert.Equal(t, reflect.TypeOf(vec3{}), stats.Type, "type mismatch: got %+v, want %+v", stats.Type, reflect.TypeOf(vec3{}))
+
+	assert.True(t, s.Set(3, &vec3{1, 2, 3}), "expected Set() to return true for valid index")
+	assert.False(t, s.Set(4, &vec3{1, 2, 3}), "expected Set() to return false for invalid index")
+	first := (*vec3)(s.Get(3))
+
+	s.Expand(1000)
+	assert.EqualValues(t, 1000, s.Stats().Cap, "expected Expand to allocate enough chunks")
+	assert.True(t, first == (*vec3)(s.Get(3)), "expected Expand to keep the items in place")
+	assert.Equal(t, vec3{1, 2, 3}, *first, "expected Expand to keep the values")
+
+	s.Copy(999, s.Get(3))
+	assert.Equal(t, vec3{1, 2, 3}, *(*vec3)(s.Get(999)), "expected Copy to copy the values")
+
+	s.Shrink(5)
+	assert.EqualValues(t, 8, s.Stats().Cap, "expected Shrink to release the unused chunks")
+	assert.True(t, first == (*vec3)(s.Get(3)), "expected Shrink to keep the items in place")
+
+	s.Reset()
+	assert.EqualValues(t, 0, s.Stats().Cap, "expected Reset to release all chunks")
+
+	big := NewStorageChunked[[2000]vec3](2, 0)
+	assert.EqualValues(t, 2, big.Stats().Cap, "expected one item per chunk for items bigger than the chunk")
+
+	empty := NewStorageChunked[tag](1, 0)
+	assert.EqualValues(t, StorageChunkSize, empty.Stats().Cap, "expected zero sized items to use chunkSize items per chunk")
+}
+
+func TestIsPointerFree(t *testing.T) {
+	type inner struct {
+		a [4]int32
+		b complex64
+	}
+	type outer struct {
+		in  inner
+		ok  bool
+		ptr uintptr
+	}
+	type withSlice struct {
+		in     inner
+		values []int
+	}
+
+	assert.True(t, isPointerFree(reflect.TypeOf(outer{})), "structs without pointers should be pointer-free")
+	assert.True(t, isPointerFree(reflect.TypeOf([0]*int{})), "empty arrays should be pointer-free")
+	assert.False(t, isPointerFree(reflect.TypeOf(withSlice{})), "structs with slices should have pointers")
+	assert.False(t, isPointerFree(reflect.TypeOf([2]string{})), "arrays of strings should have pointers")
+	assert.False(t, isPointerFree(reflect.TypeOf(new(int))), "pointers should have pointers")
 }
@@ -3,7 +3,8 @@ Package ECS implements the Entity-Component-System pattern
 
 Besides the System in the name, this package offers a Query function
 to iterate over the needed entities, leaving the systems implementation
-to the user.
+to the user. The optional Scheduler runs the systems declared with
+their component access in parallel, when they don't conflict.
 
 This implementation is modular, so you can create the ComponentRegistry directly,
 instantiate the EntityPool to control the entities alive in the project and use
@@ -25,6 +26,14 @@ type World interface {
 	NewEntity(...ComponentID) EntityID
 	// RemEntity removes the entity and it's components from the world
 	RemEntity(EntityID)
+	// InsertEntity adds the entity with the ID and generation given, like the ones received from a server.
+	// Returns false if the entity pool can't use the ID (see EntityPool.Insert)
+	InsertEntity(EntityID, ...ComponentID) bool
+	// ReserveEntity returns an EntityID that becomes alive, without components, after FlushReserved.
+	// It's safe to call from multiple goroutines, as long as the world is not changed at the same time
+	ReserveEntity() EntityID
+	// FlushReserved adds the entities returned by ReserveEntity to the world
+	FlushReserved()
 	// IsAlive returns true if the entity is alive in the world
 	IsAlive(EntityID) bool
 	// AddComponent adds another component to the entity, if the entity is alive.
@@ -33,8 +42,19 @@ type World interface {
 	// RemComponent removes a component fom the entity. This function does nothing if
 	// the component don't exist in this entity
 	RemComponent(EntityID, ComponentID)
+	// AddComponents adds the components to the entity with a single move between archetypes,
+	// instead of moving the entity once for every component as AddComponent does.
+	AddComponents(EntityID, ...ComponentID)
+	// RemComponents removes the components from the entity with a single move between archetypes
+	RemComponents(EntityID, ...ComponentID)
+	// SetComponents changes the components of the entity to the ones in the mask, adding and removing
+	// components with a single move. Components beyond MaskTotalBits are kept in unconstrained worlds.
+	SetComponents(EntityID, Mask)
 	// Component returns the component pointer for this entity.
 	Component(EntityID, ComponentID) unsafe.Pointer
+	// SetComponent copies the value, a pointer to the component type, to the entity's component.
+	// Returns false if the entity don't have the component or the value has the wrong type
+	SetComponent(EntityID, ComponentID, interface{}) bool
 	// Register adds a component registry to the world. If the component ID is
 	// already in use, this function panics
 	Register(ComponentRegistry)
@@ -42,23 +62,93 @@ type World interface {
 	// You can use the helper function MakeComponentMask(...ComponentID) to create the mask.
 	// An empty mask returns a query cursor for all entities in the world.
 	Query(Mask) QueryCursor
+	// QueryDynamic returns a QueryCursor for the DynamicMask, used to query components beyond
+	// MaskTotalBits in worlds created by NewUnconstrainedWorld.
+	// You can use the helper function MakeDynamicComponentMask(...ComponentID) to create the mask.
+	QueryDynamic(DynamicMask) QueryCursor
+	// Compact releases the memory of underused archetypes, following the CompactionPolicy.
+	// Pointers to components are invalid after this call.
+	Compact()
+	// SetCompactionPolicy changes when the memory of underused archetypes is released.
+	// See DefaultCompactionPolicy for the policy used by new worlds.
+	SetCompactionPolicy(CompactionPolicy)
+	// RemEmptyArchetypes removes the archetypes without entities, created by component combinations
+	// no longer in use. Pointers to components and archetypes are invalid after this call.
+	RemEmptyArchetypes()
+	// SetRecyclePolicy changes the order and the delay for reusing the IDs of removed entities
+	SetRecyclePolicy(RecyclePolicy)
+	// Maintain should be called once per frame to remove the archetypes that stayed empty
+	// for CompactionPolicy.ReclaimAfter frames and to count the RecyclePolicy.QuarantineFrames.
+	// Pointers to components and archetypes are invalid after this call.
+	Maintain()
+	// Stats returns the entity counts, archetypes and memory used by the components,
+	// collected in O(archetypes + columns). See WorldStats
+	Stats() WorldStats
+	// SetName gives a name to the entity, unique between the entities with the same parent.
+	// An empty name releases the entity name. Returns false if the entity is not alive,
+	// the name contains EntityPathSeparator or it's already in use.
+	SetName(EntityID, string) bool
+	// Name returns the name of the entity or an empty string if it doesn't have one
+	Name(EntityID) string
+	// SetParent sets the parent of the entity, used to compose hierarchical paths like "level1/door3".
+	// A parent 0 detaches the entity. Returns false if any entity is not alive, the entity name
+	// is already in use by a child of the parent or the relation makes a cycle.
+	SetParent(child EntityID, parent EntityID) bool
+	// Parent returns the parent of the entity or 0 if it doesn't have one
+	Parent(EntityID) EntityID
+	// Path returns the names from the root to the entity joined by EntityPathSeparator,
+	// or an empty string if any entity in the path don't have a name
+	Path(EntityID) string
+	// Lookup returns the entity with the name or path, like "player" or "level1/door3"
+	Lookup(string) (EntityID, bool)
 }
 
 type world struct {
 	entityPool EntityPool
 	factory    ComponentFactory
 	archGraph  ArchetypeGraph
+	names      *entityNames
 }
 
 /*
 NewWorld returns an implementation for the World
 */
 func NewWorld(entityPoolSize uint) World {
+	return NewWorldWithEntityPool(NewEntityPool(entityPoolSize))
+}
+
+/*
+NewWorldWithEntityPool returns an implementation for the World using the EntityPool,
+like the ones created by NewEntityPoolRange or NewPartitionedEntityPool
+*/
+func NewWorldWithEntityPool(pool EntityPool) World {
 	factory := NewComponentFactory()
 	w := &world{
-		NewEntityPool(entityPoolSize),
+		pool,
 		factory,
 		NewArchetypeGraph(factory),
+		newEntityNames(),
+	}
+	return w
+}
+
+/*
+NewUnconstrainedWorld returns an implementation for the World without the MaxComponentCount limit.
+
+Queries for components beyond MaskTotalBits must be made with QueryDynamic
+*/
+func NewUnconstrainedWorld(entityPoolSize uint) World {
+	return NewUnconstrainedWorldWithEntityPool(NewEntityPool(entityPoolSize))
+}
+
+// NewUnconstrainedWorldWithEntityPool is NewUnconstrainedWorld using the EntityPool
+func NewUnconstrainedWorldWithEntityPool(pool EntityPool) World {
+	factory := NewUnconstrainedComponentFactory()
+	w := &world{
+		pool,
+		factory,
+		NewUnconstrainedArchetypeGraph(factory),
+		newEntityNames(),
 	}
 	return w
 }
@@ -71,9 +161,28 @@ func (w *world) NewEntity(comp ...ComponentID) EntityID {
 
 func (w *world) RemEntity(id EntityID) {
 	w.archGraph.Rem(id)
+	w.names.rem(id.WithoutFlags())
 	w.entityPool.Recycle(id)
 }
 
+func (w *world) InsertEntity(id EntityID, comp ...ComponentID) bool {
+	if !w.entityPool.Insert(id) {
+		return false
+	}
+	w.archGraph.Add(id.WithoutFlags(), comp...)
+	return true
+}
+
+func (w *world) ReserveEntity() EntityID {
+	return w.entityPool.Reserve()
+}
+
+func (w *world) FlushReserved() {
+	w.entityPool.Materialize(func(id EntityID) {
+		w.archGraph.Add(id)
+	})
+}
+
 func (w *world) IsAlive(id EntityID) bool {
 	return w.entityPool.IsAlive(id)
 }
@@ -86,13 +195,24 @@ func (w *world) RemComponent(id EntityID, component ComponentID) {
 	w.archGraph.RemComponent(id, component)
 }
 
+func (w *world) AddComponents(id EntityID, components ...ComponentID) {
+	w.archGraph.AddComponents(id, components...)
+}
+
+func (w *world) RemComponents(id EntityID, components ...ComponentID) {
+	w.archGraph.RemComponents(id, components...)
+}
+
+func (w *world) SetComponents(id EntityID, mask Mask) {
+	w.archGraph.SetComponents(id, mask)
+}
+
 func (w *world) Component(entity EntityID, component ComponentID) unsafe.Pointer {
-	arch, row := w.archGraph.Get(entity)
-	column := arch.columns[component]
-	if column == nil {
-		return nil
-	}
-	return column.Get(uint(row))
+	return w.archGraph.Component(entity, component)
+}
+
+func (w *world) SetComponent(entity EntityID, component ComponentID, value interface{}) bool {
+	return w.archGraph.SetComponent(entity, component, value)
 }
 
 func (w *world) Register(comp ComponentRegistry) {
@@ -102,3 +222,65 @@ func (w *world) Register(comp ComponentRegistry) {
 func (w *world) Query(mask Mask) QueryCursor {
 	return w.archGraph.Query(mask)
 }
+
+func (w *world) QueryDynamic(mask DynamicMask) QueryCursor {
+	return w.archGraph.QueryDynamic(mask)
+}
+
+func (w *world) Compact() {
+	w.archGraph.Compact()
+}
+
+func (w *world) SetCompactionPolicy(policy CompactionPolicy) {
+	w.archGraph.SetCompactionPolicy(policy)
+}
+
+func (w *world) RemEmptyArchetypes() {
+	w.archGraph.RemEmptyArchetypes()
+}
+
+func (w *world) SetRecyclePolicy(policy RecyclePolicy) {
+	w.entityPool.SetRecyclePolicy(policy)
+}
+
+func (w *world) Maintain() {
+	w.entityPool.Maintain()
+	w.archGraph.Maintain()
+}
+
+func (w *world) Stats() WorldStats {
+	return WorldStats{
+		w.entityPool.Stats(),
+		w.archGraph.Stats(),
+	}
+}
+
+func (w *world) SetName(id EntityID, name string) bool {
+	if !w.IsAlive(id) {
+		return false
+	}
+	return w.names.setName(id.WithoutFlags(), name)
+}
+
+func (w *world) Name(id EntityID) string {
+	return w.names.name(id.WithoutFlags())
+}
+
+func (w *world) SetParent(child, parent EntityID) bool {
+	if !w.IsAlive(child) || (parent != 0 && !w.IsAlive(parent)) {
+		return false
+	}
+	return w.names.setParent(child.WithoutFlags(), parent.WithoutFlags())
+}
+
+func (w *world) Parent(id EntityID) EntityID {
+	return w.names.parent(id.WithoutFlags())
+}
+
+func (w *world) Path(id EntityID) string {
+	return w.names.path(id.WithoutFlags())
+}
+
+func (w *world) Lookup(path string) (EntityID, bool) {
+	return w.names.lookup(path)
+}
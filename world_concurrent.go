@@ -0,0 +1,294 @@
+package ecs
+
+import (
+	"sync"
+	"unsafe"
+)
+
+/*
+ConcurrentWorld is a World safe to use from multiple goroutines, like the networking and
+asset loading ones, guarded by a sync.RWMutex.
+
+Every World method locks the world for the duration of the call: IsAlive, Component, Query,
+QueryDynamic, Stats, Name, Parent, Path, Lookup and ReserveEntity take the read lock, and the
+other methods take the write lock.
+
+The pointers returned by Component and the QueryCursor returned by Query are not protected after
+the call returns, as another goroutine can change the world while they are used. Use Read to access
+the components and iterate over queries, and Write to group changes that must be seen together:
+
+	w.Read(func(world ecs.WorldReader) {
+		query := world.Query(mask)
+		for query.Next() {
+			pos := (*Position)(query.Component(PositionComponentID))
+		}
+	})
+
+Read gives fn a WorldReader, that only exposes the methods safe under the read lock. The component
+values must only be read inside Read, as other readers can run at the same time. The world given
+to fn must not be used after fn returns, and fn must not call the methods of the ConcurrentWorld,
+or it deadlocks.
+*/
+type ConcurrentWorld interface {
+	World
+	// Read calls fn with the world locked for reading, so other readers can run at the same time
+	Read(fn func(WorldReader))
+	// Write calls fn with the world locked for writing
+	Write(fn func(World))
+}
+
+// WorldReader is the read-only view of the World given to ConcurrentWorld.Read
+type WorldReader interface {
+	// IsAlive returns true if the entity is alive in the world
+	IsAlive(EntityID) bool
+	// Component returns the pointer to the component of the entity, or nil if it doesn't have it.
+	// The value must only be read, as other readers can use it at the same time
+	Component(EntityID, ComponentID) unsafe.Pointer
+	// Query returns a QueryCursor for the entities with all the components of the mask
+	Query(Mask) QueryCursor
+	// QueryDynamic returns a QueryCursor for the DynamicMask
+	QueryDynamic(DynamicMask) QueryCursor
+	// Stats returns the statistics of the world
+	Stats() WorldStats
+	// Name returns the name of the entity or an empty string if it doesn't have one
+	Name(EntityID) string
+	// Parent returns the parent of the entity or 0 if it doesn't have one
+	Parent(EntityID) EntityID
+	// Path returns the names from the root to the entity joined by EntityPathSeparator
+	Path(EntityID) string
+	// Lookup returns the entity with the name or path
+	Lookup(string) (EntityID, bool)
+}
+
+// worldReader wraps the World, so the fn given to Read can't convert it back to change the world
+type worldReader struct {
+	world World
+}
+
+func (r worldReader) IsAlive(id EntityID) bool {
+	return r.world.IsAlive(id)
+}
+
+func (r worldReader) Component(entity EntityID, component ComponentID) unsafe.Pointer {
+	return r.world.Component(entity, component)
+}
+
+func (r worldReader) Query(mask Mask) QueryCursor {
+	return r.world.Query(mask)
+}
+
+func (r worldReader) QueryDynamic(mask DynamicMask) QueryCursor {
+	return r.world.QueryDynamic(mask)
+}
+
+func (r worldReader) Stats() WorldStats {
+	return r.world.Stats()
+}
+
+func (r worldReader) Name(id EntityID) string {
+	return r.world.Name(id)
+}
+
+func (r worldReader) Parent(id EntityID) EntityID {
+	return r.world.Parent(id)
+}
+
+func (r worldReader) Path(id EntityID) string {
+	return r.world.Path(id)
+}
+
+func (r worldReader) Lookup(path string) (EntityID, bool) {
+	return r.world.Lookup(path)
+}
+
+type concurrentWorld struct {
+	mu    sync.RWMutex
+	world World
+}
+
+// NewConcurrentWorld returns a ConcurrentWorld that guards the world.
+// The world must not be used directly after this call.
+func NewConcurrentWorld(w World) ConcurrentWorld {
+	return &concurrentWorld{world: w}
+}
+
+func (c *concurrentWorld) Read(fn func(WorldReader)) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	fn(worldReader{c.world})
+}
+
+func (c *concurrentWorld) Write(fn func(World)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fn(c.world)
+}
+
+func (c *concurrentWorld) NewEntity(comp ...ComponentID) EntityID {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.world.NewEntity(comp...)
+}
+
+func (c *concurrentWorld) RemEntity(id EntityID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.world.RemEntity(id)
+}
+
+func (c *concurrentWorld) InsertEntity(id EntityID, comp ...ComponentID) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.world.InsertEntity(id, comp...)
+}
+
+func (c *concurrentWorld) ReserveEntity() EntityID {
+	// reservations are atomic, they only need to exclude FlushReserved and InsertEntity
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.world.ReserveEntity()
+}
+
+func (c *concurrentWorld) FlushReserved() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.world.FlushReserved()
+}
+
+func (c *concurrentWorld) IsAlive(id EntityID) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.world.IsAlive(id)
+}
+
+func (c *concurrentWorld) AddComponent(id EntityID, component ComponentID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.world.AddComponent(id, component)
+}
+
+func (c *concurrentWorld) RemComponent(id EntityID, component ComponentID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.world.RemComponent(id, component)
+}
+
+func (c *concurrentWorld) AddComponents(id EntityID, components ...ComponentID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.world.AddComponents(id, components...)
+}
+
+func (c *concurrentWorld) RemComponents(id EntityID, components ...ComponentID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.world.RemComponents(id, components...)
+}
+
+func (c *concurrentWorld) SetComponents(id EntityID, mask Mask) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.world.SetComponents(id, mask)
+}
+
+func (c *concurrentWorld) Component(entity EntityID, component ComponentID) unsafe.Pointer {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.world.Component(entity, component)
+}
+
+func (c *concurrentWorld) SetComponent(entity EntityID, component ComponentID, value interface{}) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.world.SetComponent(entity, component, value)
+}
+
+func (c *concurrentWorld) Register(comp ComponentRegistry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.world.Register(comp)
+}
+
+func (c *concurrentWorld) Query(mask Mask) QueryCursor {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.world.Query(mask)
+}
+
+func (c *concurrentWorld) QueryDynamic(mask DynamicMask) QueryCursor {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.world.QueryDynamic(mask)
+}
+
+func (c *concurrentWorld) Compact() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.world.Compact()
+}
+
+func (c *concurrentWorld) SetCompactionPolicy(policy CompactionPolicy) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.world.SetCompactionPolicy(policy)
+}
+
+func (c *concurrentWorld) RemEmptyArchetypes() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.world.RemEmptyArchetypes()
+}
+
+func (c *concurrentWorld) SetRecyclePolicy(policy RecyclePolicy) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.world.SetRecyclePolicy(policy)
+}
+
+func (c *concurrentWorld) Maintain() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.world.Maintain()
+}
+
+func (c *concurrentWorld) Stats() WorldStats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.world.Stats()
+}
+
+func (c *concurrentWorld) SetName(id EntityID, name string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.world.SetName(id, name)
+}
+
+func (c *concurrentWorld) Name(id EntityID) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.world.Name(id)
+}
+
+func (c *concurrentWorld) SetParent(child, parent EntityID) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.world.SetParent(child, parent)
+}
+
+func (c *concurrentWorld) Parent(id EntityID) EntityID {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.world.Parent(id)
+}
+
+func (c *concurrentWorld) Path(id EntityID) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.world.Path(id)
+}
+
+func (c *concurrentWorld) Lookup(path string) (EntityID, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.world.Lookup(path)
+}
@@ -0,0 +1,178 @@
+package ecs
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrentWorld(t *testing.T) {
+	const (
+		PositionCompID ComponentID = iota
+		VelocityCompID
+		LoadedCompID
+	)
+	type Position struct{ x, y float32 }
+	type Velocity struct{ x, y float32 }
+	type Loaded struct{}
+
+	w := NewConcurrentWorld(NewWorld(0))
+	w.Register(NewComponentRegistry[Position](PositionCompID))
+	w.Register(NewComponentRegistry[Velocity](VelocityCompID))
+	w.Register(NewSparseComponentRegistry[Loaded](LoadedCompID))
+
+	const goroutines, perGoroutine = 4, 200
+	var wg sync.WaitGroup
+	created := make([][]EntityID, goroutines)
+
+	for g := 0; g < goroutines; g++ {
+		wg.Add(3)
+
+		// asset loading: creates entities and changes their components
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < perGoroutine; i++ {
+				e := w.NewEntity(PositionCompID)
+				w.SetComponent(e, PositionCompID, &Position{float32(i), float32(g)})
+				w.AddComponents(e, VelocityCompID, LoadedCompID)
+				w.SetName(e, "")
+				created[g] = append(created[g], e)
+			}
+		}(g)
+
+		// game loop: iterates over the entities while they are created
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perGoroutine/10; i++ {
+				w.Read(func(world WorldReader) {
+					query := world.Query(MakeComponentMask(PositionCompID, VelocityCompID, LoadedCompID))
+					for query.Next() {
+						pos := (*Position)(query.Component(PositionCompID))
+						_ = pos.x + pos.y
+					}
+				})
+				w.Write(func(world World) {
+					query := world.Query(MakeComponentMask(PositionCompID, VelocityCompID))
+					for query.Next() {
+						vel := (*Velocity)(query.Component(VelocityCompID))
+						vel.x++
+					}
+				})
+			}
+		}()
+
+		// networking: reserves IDs and reads the world state
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perGoroutine; i++ {
+				w.ReserveEntity()
+				w.IsAlive(EntityID(i))
+				w.Component(EntityID(i), PositionCompID)
+				w.Stats()
+				w.Lookup("player")
+			}
+		}()
+	}
+	wg.Wait()
+
+	w.FlushReserved()
+	w.Read(func(world WorldReader) {
+		_, writable := world.(World)
+		assert.False(t, writable, "expected Read to give a read-only view of the world")
+	})
+	assert.Equal(t, uint(goroutines*perGoroutine*2), w.Stats().EntityPool.Alive, "expected all the entities created and reserved")
+	for g, entities := range created {
+		for i, e := range entities {
+			w.Read(func(world WorldReader) {
+				assert.Equal(t, Position{float32(i), float32(g)}, *(*Position)(world.Component(e, PositionCompID)), "expected the component values")
+				assert.NotNil(t, world.Component(e, LoadedCompID), "expected the sparse components")
+			})
+		}
+	}
+
+	other := NewConcurrentWorld(NewWorld(0))
+	other.Register(NewComponentRegistry[Position](PositionCompID))
+	other.Register(NewComponentRegistry[Velocity](VelocityCompID))
+	other.Register(NewSparseComponentRegistry[Loaded](LoadedCompID))
+
+	// moves in both directions at the same time must not deadlock
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for _, e := range created[0] {
+			MoveEntity(w, other, e)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < perGoroutine; i++ {
+			MoveEntity(other, w, other.NewEntity(PositionCompID))
+		}
+	}()
+	wg.Wait()
+
+	assert.EqualValues(t, perGoroutine, other.Stats().EntityPool.Alive, "expected MoveEntity to move between concurrent worlds")
+	assert.EqualValues(t, goroutines*perGoroutine*2, w.Stats().EntityPool.Alive, "expected MoveEntity to move between concurrent worlds")
+}
+
+func TestConcurrentWorldMethods(t *testing.T) {
+	const (
+		PositionCompID ComponentID = iota
+		VelocityCompID
+	)
+	type Position struct{ x, y float32 }
+	type Velocity struct{ x, y float32 }
+
+	w := NewConcurrentWorld(NewWorld(0))
+	w.Register(NewComponentRegistry[Position](PositionCompID))
+	w.Register(NewComponentRegistry[Velocity](VelocityCompID))
+	w.SetCompactionPolicy(DefaultCompactionPolicy)
+	w.SetRecyclePolicy(RecyclePolicy{QuarantineFrames: 1})
+
+	level := w.NewEntity()
+	door := w.NewEntity()
+	assert.True(t, w.SetName(level, "level1") && w.SetName(door, "door3"), "expected SetName to name the entities")
+	assert.True(t, w.SetParent(door, level), "expected SetParent to change the parent")
+	assert.Equal(t, level, w.Parent(door), "expected Parent to return the parent")
+	assert.Equal(t, "door3", w.Name(door), "expected Name to return the name")
+	assert.Equal(t, "level1/door3", w.Path(door), "expected Path to return the path")
+
+	w.AddComponent(door, PositionCompID)
+	w.AddComponents(door, VelocityCompID)
+	assert.NotNil(t, w.Component(door, VelocityCompID), "expected AddComponents to add the components")
+	w.RemComponent(door, VelocityCompID)
+	assert.True(t, w.Component(door, VelocityCompID) == nil, "expected RemComponent to remove the component")
+	w.SetComponents(door, MakeComponentMask(PositionCompID, VelocityCompID))
+	assert.NotNil(t, w.Component(door, VelocityCompID), "expected SetComponents to add the components")
+	w.RemComponents(door, PositionCompID)
+	assert.True(t, w.Component(door, PositionCompID) == nil, "expected RemComponents to remove the components")
+
+	query := w.Query(MakeComponentMask(VelocityCompID))
+	assert.True(t, query.Next() && query.Entity() == door, "expected Query to find the entity")
+	query = w.QueryDynamic(MakeDynamicComponentMask(VelocityCompID))
+	assert.True(t, query.Next() && query.Entity() == door, "expected QueryDynamic to find the entity")
+
+	w.Read(func(world WorldReader) {
+		assert.True(t, world.IsAlive(door), "expected IsAlive to find the entity")
+		assert.Equal(t, "door3", world.Name(door), "expected Name to return the name")
+		assert.Equal(t, level, world.Parent(door), "expected Parent to return the parent")
+		assert.Equal(t, "level1/door3", world.Path(door), "expected Path to return the path")
+		found, ok := world.Lookup("level1/door3")
+		assert.True(t, ok && found == door, "expected Lookup to resolve the path")
+		assert.EqualValues(t, 2, world.Stats().EntityPool.Alive, "expected Stats to count the entities")
+		query := world.Query(MakeComponentMask(VelocityCompID))
+		assert.True(t, query.Next() && query.Entity() == door, "expected Query to find the entity")
+		query = world.QueryDynamic(MakeDynamicComponentMask(VelocityCompID))
+		assert.True(t, query.Next() && query.Entity() == door, "expected QueryDynamic to find the entity")
+	})
+
+	w.RemEntity(door)
+	assert.False(t, w.IsAlive(door), "expected RemEntity to remove the entity")
+	w.Maintain()
+	assert.False(t, w.InsertEntity(door), "expected InsertEntity to reject removed entities")
+	assert.True(t, w.InsertEntity(MakeEntity(door.ID(), door.Gen()+1), PositionCompID), "expected InsertEntity to accept the next generation")
+	w.Compact()
+	w.RemEmptyArchetypes()
+	assert.EqualValues(t, 2, w.Stats().EntityPool.Alive, "expected the entities to survive the maintenance")
+}
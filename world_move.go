@@ -0,0 +1,124 @@
+package ecs
+
+import "unsafe"
+
+/*
+MoveEntity transfers the entity and its components from the src World to the dst World,
+returning the new EntityID in dst, or 0 if the entity is not alive in src.
+
+Every component of the entity must be registered in dst with the same ComponentID and type,
+or this function panics without changing the worlds. Singleton components are not copied, as they
+keep one value per registry. The entity name is moved if it's not in use by another root entity
+of dst, and the parent and children relations are discarded.
+
+Both worlds must be created by this package. A ConcurrentWorld is locked for writing during the move,
+other worlds can't be used by other goroutines at the same time:
+
+	// in the main goroutine, after the loading goroutine finished with the staging world
+	for _, e := range loaded {
+		ecs.MoveEntity(staging, world, e)
+	}
+*/
+func MoveEntity(src, dst World, entity EntityID) EntityID {
+	defer lockWorlds(src, dst)()
+
+	from, to := asWorld(src), asWorld(dst)
+	if !from.IsAlive(entity) {
+		return 0
+	}
+
+	fromGraph, toGraph := asArchetypeGraph(from.archGraph), asArchetypeGraph(to.archGraph)
+	components := fromGraph.entityComponents(entity)
+
+	for _, id := range components {
+		fromReg, _ := from.factory.GetByID(id)
+		toReg, ok := to.factory.GetByID(id)
+		if !ok || toReg.Type != fromReg.Type || toReg.kind != fromReg.kind {
+			panic("MoveEntity: component not registered with the same type in the destination world")
+		}
+	}
+
+	moved := to.NewEntity(components...)
+	for _, id := range components {
+		if reg, _ := from.factory.GetByID(id); reg.kind == componentKindSingleton || reg.IsTag() {
+			continue
+		}
+		fromColumn, fromRow := fromGraph.storageFor(entity, id)
+		toColumn, toRow := toGraph.storageFor(moved, id)
+		toColumn.CopyRange(toRow, fromColumn, fromRow, 1)
+	}
+
+	name := from.Name(entity)
+	from.RemEntity(entity)
+	if name != "" {
+		to.SetName(moved, name)
+	}
+
+	return moved
+}
+
+// entityComponents returns the components of the entity, including the sparse ones
+func (a *archetypeGraph) entityComponents(entity EntityID) []ComponentID {
+	var components []ComponentID
+
+	if cache, ok := a.entityIndex.get(entity); ok {
+		arch := &a.archetypes[cache.archetype]
+		if arch.dynMask != nil {
+			for bit := arch.dynMask.NextBitSet(0); bit < arch.dynMask.TotalBits(); bit = arch.dynMask.NextBitSet(bit + 1) {
+				components = append(components, ComponentID(bit))
+			}
+		} else {
+			for bit := arch.mask.NextBitSet(0); bit < MaskTotalBits; bit = arch.mask.NextBitSet(bit + 1) {
+				components = append(components, ComponentID(bit))
+			}
+		}
+	}
+
+	for id, set := range a.sparse {
+		if set != nil && set.has(entity) {
+			components = append(components, ComponentID(id))
+		}
+	}
+	return components
+}
+
+// lockWorlds locks the ConcurrentWorlds in address order, so concurrent moves in both directions
+// don't deadlock, and returns the function to unlock them
+func lockWorlds(src, dst World) func() {
+	var locked []*concurrentWorld
+	for _, w := range []World{src, dst} {
+		if cw, ok := w.(*concurrentWorld); ok && (len(locked) == 0 || locked[0] != cw) {
+			locked = append(locked, cw)
+		}
+	}
+	if len(locked) == 2 && uintptr(unsafe.Pointer(locked[1])) < uintptr(unsafe.Pointer(locked[0])) {
+		locked[0], locked[1] = locked[1], locked[0]
+	}
+	for _, cw := range locked {
+		cw.mu.Lock()
+	}
+	return func() {
+		for _, cw := range locked {
+			cw.mu.Unlock()
+		}
+	}
+}
+
+func asWorld(w World) *world {
+	if cw, ok := w.(*concurrentWorld); ok {
+		w = cw.world
+	}
+	impl, ok := w.(*world)
+	if !ok {
+		panic("MoveEntity only supports worlds created by this package")
+	}
+	return impl
+}
+
+func asArchetypeGraph(graph ArchetypeGraph) *archetypeGraph {
+	impl, ok := graph.(*archetypeGraph)
+	if !ok {
+		panic("MoveEntity only supports worlds using the ArchetypeGraph created by this package")
+	}
+	return impl
+}
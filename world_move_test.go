@@ -0,0 +1,89 @@
+package ecs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMoveEntity(t *testing.T) {
+	const (
+		PositionCompID ComponentID = iota
+		NameCompID
+		EnemyCompID
+		HoveredCompID
+		ConfigCompID
+		MaterialCompID
+		HealthCompID ComponentID = 300
+	)
+	type Position struct{ x, y float32 }
+	type Name struct{ value string }
+	type Enemy struct{}
+	type Hovered struct{ time float32 }
+	type Config struct{ gravity float32 }
+	type Material struct{ color uint32 }
+	type Health struct{ value float32 }
+
+	config := NewSingletonComponentRegistry[Config](ConfigCompID)
+	material := NewSharedComponentRegistry[Material](MaterialCompID)
+	register := func(w World) {
+		w.Register(NewComponentRegistry[Position](PositionCompID))
+		w.Register(NewComponentRegistry[Name](NameCompID))
+		w.Register(NewComponentRegistry[Enemy](EnemyCompID))
+		w.Register(NewSparseComponentRegistry[Hovered](HoveredCompID))
+		w.Register(config)
+		w.Register(material)
+	}
+
+	staging, main := NewWorld(0), NewWorld(0)
+	register(staging)
+	register(main)
+
+	main.NewEntity(PositionCompID)
+	e := staging.NewEntity(PositionCompID, NameCompID, EnemyCompID, HoveredCompID, ConfigCompID, MaterialCompID)
+	staging.SetComponent(e, PositionCompID, &Position{1, 2})
+	staging.SetComponent(e, NameCompID, &Name{"orc"})
+	staging.SetComponent(e, HoveredCompID, &Hovered{0.5})
+	staging.SetComponent(e, ConfigCompID, &Config{9.8})
+	staging.SetComponent(e, MaterialCompID, &Material{0xff0000})
+	staging.SetName(e, "orc")
+
+	moved := MoveEntity(staging, main, e)
+	assert.NotZero(t, moved, "MoveEntity should return the new entity")
+	assert.False(t, staging.IsAlive(e), "MoveEntity should remove the entity from the source world")
+	assert.True(t, main.IsAlive(moved), "MoveEntity should create the entity in the destination world")
+
+	assert.Equal(t, Position{1, 2}, *(*Position)(main.Component(moved, PositionCompID)), "MoveEntity should copy the components")
+	assert.Equal(t, Name{"orc"}, *(*Name)(main.Component(moved, NameCompID)), "MoveEntity should copy the components")
+	assert.Equal(t, Hovered{0.5}, *(*Hovered)(main.Component(moved, HoveredCompID)), "MoveEntity should copy sparse components")
+	assert.Equal(t, Config{9.8}, *(*Config)(main.Component(moved, ConfigCompID)), "MoveEntity should keep the singleton value")
+	assert.Equal(t, Material{0xff0000}, *(*Material)(main.Component(moved, MaterialCompID)), "MoveEntity should copy shared components")
+	assert.NotNil(t, main.Component(moved, EnemyCompID), "MoveEntity should copy tags")
+
+	found, ok := main.Lookup("orc")
+	assert.True(t, ok && found == moved, "MoveEntity should move the entity name")
+	_, ok = staging.Lookup("orc")
+	assert.False(t, ok, "MoveEntity should release the name in the source world")
+
+	assert.Zero(t, MoveEntity(staging, main, e), "MoveEntity should return 0 for entities not alive")
+
+	incompatible := NewWorld(0)
+	incompatible.Register(NewComponentRegistry[Name](PositionCompID))
+	assert.Panics(t, func() {
+		MoveEntity(main, incompatible, moved)
+	}, "MoveEntity should panic for components with different types")
+	assert.True(t, main.IsAlive(moved), "MoveEntity should not change the worlds when it panics")
+
+	unconstrained := NewUnconstrainedWorld(0)
+	unconstrained.Register(NewComponentRegistry[Health](HealthCompID))
+	wounded := unconstrained.NewEntity(HealthCompID)
+	unconstrained.SetComponent(wounded, HealthCompID, &Health{3})
+	assert.Panics(t, func() {
+		MoveEntity(unconstrained, main, wounded)
+	}, "MoveEntity should panic for components not registered in the destination world")
+
+	other := NewUnconstrainedWorld(0)
+	other.Register(NewComponentRegistry[Health](HealthCompID))
+	movedWounded := MoveEntity(unconstrained, other, wounded)
+	assert.Equal(t, Health{3}, *(*Health)(other.Component(movedWounded, HealthCompID)), "MoveEntity should copy components beyond MaskTotalBits")
+}
@@ -1,6 +1,7 @@
 package ecs
 
 import (
+	"sync"
 	"testing"
 	"unsafe"
 
@@ -62,3 +63,414 @@ func Test_ECS_Component(t *testing.T) {
 	b := (*CompB)(w.Component(someEntity, CompBID))
 	assert.Nil(t, b)
 }
+
+func TestWorldSharedComponents(t *testing.T) {
+	const (
+		MaterialCompID ComponentID = iota
+		PositionCompID
+	)
+	type Material struct{ color uint32 }
+	type Position struct{ x, y float32 }
+
+	world := NewWorld(0)
+	world.Register(NewSharedComponentRegistry[Material](MaterialCompID))
+	world.Register(NewComponentRegistry[Position](PositionCompID))
+
+	red, blue := Material{0xff0000}, Material{0x0000ff}
+
+	entities := make([]EntityID, 0)
+	for i := 0; i < 10; i++ {
+		comp := []ComponentID{MaterialCompID}
+		if i%2 == 0 {
+			comp = append(comp, PositionCompID)
+		}
+		e := world.NewEntity(comp...)
+		material := &red
+		if i%3 == 0 {
+			material = &blue
+		}
+		assert.True(t, world.SetComponent(e, MaterialCompID, material), "SetComponent should accept valid values")
+		entities = append(entities, e)
+	}
+	assert.False(t, world.SetComponent(entities[1], PositionCompID, &Position{}), "SetComponent should fail for missing components")
+	assert.False(t, world.SetComponent(entities[0], MaterialCompID, &Position{}), "SetComponent should fail for wrong types")
+
+	assert.True(t, world.Component(entities[1], MaterialCompID) == world.Component(entities[2], MaterialCompID),
+		"entities with the same value should share the same pointer")
+	assert.False(t, world.Component(entities[0], MaterialCompID) == world.Component(entities[1], MaterialCompID),
+		"entities with different values should not share the same pointer")
+
+	// moving the entity to another archetype keeps the shared value
+	world.AddComponent(entities[1], PositionCompID)
+	world.RemComponent(entities[0], PositionCompID)
+	assert.Equal(t, red, *(*Material)(world.Component(entities[1], MaterialCompID)), "shared values should survive archetype moves")
+	assert.Equal(t, blue, *(*Material)(world.Component(entities[0], MaterialCompID)), "shared values should survive archetype moves")
+
+	query := world.Query(MakeComponentMask(MaterialCompID))
+	groups := query.GroupBy(MaterialCompID)
+	counts := make(map[Material]int)
+	for groups.NextGroup() {
+		material := *(*Material)(groups.Value())
+		for groups.Next() {
+			assert.Equal(t, material, *(*Material)(groups.Component(MaterialCompID)), "group entities should share the group value")
+			assert.True(t, world.IsAlive(groups.Entity()), "group entities should be valid")
+			counts[material]++
+		}
+	}
+	assert.Equal(t, map[Material]int{red: 6, blue: 4}, counts, "GroupBy should group the entities by value")
+
+	groups = world.Query(Mask{}).GroupBy(PositionCompID)
+	total := 0
+	for groups.NextGroup() {
+		for groups.Next() {
+			total++
+		}
+	}
+	assert.Equal(t, 5, total, "GroupBy should ignore entities without the component")
+
+	registry := NewSharedComponentRegistry[Material](MaterialCompID)
+	assert.True(t, registry.IsShared(), "NewSharedComponentRegistry should return a shared registry")
+	values := registry.NewStorage().(*sharedStorage[Material]).values
+
+	// worlds in different goroutines share the values of the registry
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			w := NewWorld(0)
+			w.Register(registry)
+			w.Register(NewComponentRegistry[Position](PositionCompID))
+			spawned := make([]EntityID, 100)
+			for i := range spawned {
+				spawned[i] = w.NewEntity(MaterialCompID)
+				w.SetComponent(spawned[i], MaterialCompID, &Material{uint32(i % 10)})
+				w.AddComponent(spawned[i], PositionCompID)
+			}
+			for i, e := range spawned {
+				assert.Equal(t, Material{uint32(i % 10)}, *(*Material)(w.Component(e, MaterialCompID)), "expected the shared value")
+				w.RemEntity(e)
+			}
+		}(g)
+	}
+	wg.Wait()
+	assert.Zero(t, values.len(), "values should be released when no entity uses them")
+
+	// the zero value is a shared value like the others
+	zeroWorld, otherWorld := NewWorld(0), NewWorld(0)
+	zeroWorld.Register(registry)
+	otherWorld.Register(registry)
+	colored := zeroWorld.NewEntity(MaterialCompID)
+	zeroWorld.SetComponent(colored, MaterialCompID, &red)
+	(*Material)(zeroWorld.Component(zeroWorld.NewEntity(MaterialCompID), MaterialCompID)).color = 0x00ff00
+	assert.Equal(t, red, *(*Material)(zeroWorld.Component(colored, MaterialCompID)), "writing to the zero value should not change the other values")
+	assert.Equal(t, Material{}, *(*Material)(otherWorld.Component(otherWorld.NewEntity(MaterialCompID), MaterialCompID)), "writing to the zero value should not change the new entities")
+	assert.Equal(t, Material{}, *(*Material)(zeroWorld.Component(zeroWorld.NewEntity(MaterialCompID), MaterialCompID)), "writing to the zero value should not change the new entities")
+
+	storage := registry.NewStorage()
+	storage.Expand(ComponentStorageInitialCap + 1)
+	assert.False(t, storage.Set(ComponentStorageInitialCap+ComponentStorageIncrement+1, &red), "Set should fail for invalid rows")
+	storage.Set(0, &red)
+	ptr := (*Material)(storage.Get(0))
+	ptr.color = 0xffffff
+	storage.Copy(1, unsafe.Pointer(&red))
+	assert.Equal(t, red, *(*Material)(storage.Get(1)), "values changed by their pointers should not be reused")
+	storage.Shrink(1)
+	assert.EqualValues(t, 1, storage.Stats().Cap, "Shrink should reduce the capacity")
+	storage.Reset()
+	assert.EqualValues(t, 0, storage.Stats().Cap, "Reset should discard the rows")
+}
+
+func TestWorldSparseComponents(t *testing.T) {
+	const (
+		PositionCompID ComponentID = iota
+		HealthCompID
+		StunnedCompID
+		UnusedCompID
+	)
+	type Position struct{ x, y float32 }
+	type Health struct{ value int }
+	type Stunned struct{}
+
+	w := NewWorld(0)
+	w.Register(NewComponentRegistry[Position](PositionCompID))
+	w.Register(NewSparseComponentRegistry[Health](HealthCompID))
+	w.Register(NewSparseComponentRegistry[Stunned](StunnedCompID))
+	w.Register(NewSparseComponentRegistry[Health](UnusedCompID))
+
+	entities := make([]EntityID, 0)
+	for i := 0; i < 10; i++ {
+		comp := []ComponentID{PositionCompID}
+		if i%2 == 0 {
+			comp = append(comp, HealthCompID)
+		}
+		entities = append(entities, w.NewEntity(comp...))
+	}
+	arch, _ := w.(*world).archGraph.Get(entities[0])
+	assert.Equal(t, MakeComponentMask(PositionCompID), arch.mask, "sparse components should not be in the archetype mask")
+
+	for i, e := range entities {
+		w.AddComponent(e, StunnedCompID)
+		w.AddComponent(e, StunnedCompID)
+		if i%2 == 0 {
+			assert.True(t, w.SetComponent(e, HealthCompID, &Health{i}), "SetComponent should accept valid values")
+		}
+	}
+	assert.False(t, w.SetComponent(entities[1], HealthCompID, &Health{}), "SetComponent should fail for missing components")
+	assert.False(t, w.SetComponent(entities[0], HealthCompID, &Position{}), "SetComponent should fail for wrong types")
+	assert.True(t, w.Component(entities[1], HealthCompID) == nil, "Component should be nil for missing components")
+
+	arch2, _ := w.(*world).archGraph.Get(entities[0])
+	assert.True(t, arch == arch2, "sparse components should not move the entity between archetypes")
+
+	w.RemComponent(entities[2], StunnedCompID)
+	w.RemComponent(entities[3], StunnedCompID)
+	w.RemEntity(entities[4])
+	assert.Equal(t, Health{8}, *(*Health)(w.Component(entities[8], HealthCompID)), "swapped values should be preserved")
+
+	found := make([]EntityID, 0)
+	query := w.Query(MakeComponentMask(PositionCompID, HealthCompID, StunnedCompID))
+	for query.Next() {
+		health := (*Health)(query.Component(HealthCompID))
+		assert.Equal(t, query.Entity(), entities[health.value], "query should return the entity component")
+		assert.NotNil(t, query.Component(PositionCompID), "query should return the archetype components")
+		found = append(found, query.Entity())
+	}
+	assert.ElementsMatch(t, []EntityID{entities[0], entities[6], entities[8]}, found, "query should filter by the sparse components")
+
+	query = w.Query(MakeComponentMask(UnusedCompID))
+	assert.False(t, query.Next(), "query should not return entities for unused sparse components")
+
+	registry := NewSparseComponentRegistry[Health](HealthCompID)
+	assert.True(t, registry.IsSparse(), "NewSparseComponentRegistry should return a sparse registry")
+}
+
+func TestWorldSparseComponentsHighRange(t *testing.T) {
+	const (
+		PositionCompID ComponentID = iota
+		StunnedCompID
+	)
+	type Position struct{ x, y float32 }
+	type Stunned struct{ time float32 }
+
+	w := NewWorldWithEntityPool(NewEntityPoolRange(0, EntityRangeHigh))
+	w.Register(NewComponentRegistry[Position](PositionCompID))
+	w.Register(NewSparseComponentRegistry[Stunned](StunnedCompID))
+
+	first := w.NewEntity(PositionCompID, StunnedCompID)
+	second := w.NewEntity(PositionCompID, StunnedCompID)
+	assert.True(t, EntityRangeHigh.Contains(first), "expected IDs in the high range")
+	assert.True(t, w.SetComponent(second, StunnedCompID, &Stunned{2}), "SetComponent should work for high IDs")
+
+	set := w.(*world).archGraph.(*archetypeGraph).sparse[StunnedCompID]
+	pages := 0
+	for _, page := range set.sparse {
+		if page != nil {
+			pages++
+		}
+	}
+	assert.Equal(t, 1, pages, "expected only the page of the IDs to be allocated")
+
+	w.RemComponent(first, StunnedCompID)
+	assert.True(t, w.Component(first, StunnedCompID) == nil, "RemComponent should remove high IDs from the set")
+	assert.Equal(t, Stunned{2}, *(*Stunned)(w.Component(second, StunnedCompID)), "moved values should be preserved")
+}
+
+func TestWorldComponentsBatch(t *testing.T) {
+	const (
+		PositionCompID ComponentID = iota
+		VelocityCompID
+		HealthCompID
+		EnemyCompID
+		StunnedCompID
+		FrozenCompID
+		ArmorCompID ComponentID = 400
+	)
+	type Position struct{ x, y float32 }
+	type Velocity struct{ x, y float32 }
+	type Health struct{ value float32 }
+	type Enemy struct{}
+	type Stunned struct{}
+	type Frozen struct{ time float32 }
+	type Armor struct{ value float32 }
+
+	for _, w := range []World{NewWorld(0), NewUnconstrainedWorld(0)} {
+		w.Register(NewComponentRegistry[Position](PositionCompID))
+		w.Register(NewComponentRegistry[Velocity](VelocityCompID))
+		w.Register(NewComponentRegistry[Health](HealthCompID))
+		w.Register(NewComponentRegistry[Enemy](EnemyCompID))
+		w.Register(NewSparseComponentRegistry[Stunned](StunnedCompID))
+		w.Register(NewSparseComponentRegistry[Frozen](FrozenCompID))
+		graph := w.(*world).archGraph.(*archetypeGraph)
+
+		e := w.NewEntity(PositionCompID)
+		w.SetComponent(e, PositionCompID, &Position{1, 2})
+
+		w.AddComponents(e, VelocityCompID, HealthCompID, EnemyCompID, StunnedCompID)
+		assert.Len(t, graph.archetypes, 3, "AddComponents should not create intermediate archetypes")
+		assert.Equal(t, Position{1, 2}, *(*Position)(w.Component(e, PositionCompID)), "AddComponents should keep the values")
+		assert.True(t, w.SetComponent(e, HealthCompID, &Health{10}), "AddComponents should add all the components")
+		assert.NotNil(t, w.Component(e, StunnedCompID), "AddComponents should add sparse components")
+
+		w.RemComponents(e, PositionCompID, EnemyCompID, StunnedCompID)
+		assert.Len(t, graph.archetypes, 4, "RemComponents should not create intermediate archetypes")
+		assert.True(t, w.Component(e, PositionCompID) == nil, "RemComponents should remove the components")
+		assert.True(t, w.Component(e, StunnedCompID) == nil, "RemComponents should remove sparse components")
+		assert.Equal(t, Health{10}, *(*Health)(w.Component(e, HealthCompID)), "RemComponents should keep the values")
+
+		w.SetComponents(e, MakeComponentMask(PositionCompID, HealthCompID, StunnedCompID))
+		assert.Len(t, graph.archetypes, 5, "SetComponents should not create intermediate archetypes")
+		assert.True(t, w.Component(e, VelocityCompID) == nil, "SetComponents should remove the components not in the mask")
+		assert.NotNil(t, w.Component(e, PositionCompID), "SetComponents should add the components in the mask")
+		assert.NotNil(t, w.Component(e, StunnedCompID), "SetComponents should add sparse components")
+		assert.Equal(t, Health{10}, *(*Health)(w.Component(e, HealthCompID)), "SetComponents should keep the values")
+
+		w.SetComponents(e, MakeComponentMask(PositionCompID, HealthCompID))
+		assert.True(t, w.Component(e, StunnedCompID) == nil, "SetComponents should remove sparse components")
+		w.AddComponents(e, PositionCompID)
+		assert.Len(t, graph.archetypes, 5, "adding existing components should not move the entity")
+
+		// the sparse set of Frozen is created by SetComponents
+		w.SetComponents(e, MakeComponentMask(PositionCompID, HealthCompID, FrozenCompID))
+		assert.Len(t, graph.archetypes, 5, "SetComponents should not add sparse components to the archetypes")
+		assert.True(t, w.SetComponent(e, FrozenCompID, &Frozen{3}), "SetComponents should add sparse components never used")
+		assert.Equal(t, Frozen{3}, *(*Frozen)(w.Component(e, FrozenCompID)), "SetComponents should add sparse components never used")
+		query := w.Query(MakeComponentMask(PositionCompID, FrozenCompID))
+		assert.True(t, query.Next() && query.Entity() == e, "queries should find the sparse components added by SetComponents")
+		w.RemComponent(e, FrozenCompID)
+		assert.True(t, w.Component(e, FrozenCompID) == nil, "RemComponent should remove the sparse components added by SetComponents")
+
+		w.AddComponents(w.NewEntity().SetID(1000), PositionCompID)
+		assert.Len(t, graph.archetypes, 5, "AddComponents should ignore entities not in the world")
+	}
+
+	w := NewUnconstrainedWorld(0)
+	w.Register(NewComponentRegistry[Position](PositionCompID))
+	w.Register(NewComponentRegistry[Armor](ArmorCompID))
+
+	e := w.NewEntity()
+	w.AddComponents(e, PositionCompID, ArmorCompID)
+	w.SetComponent(e, ArmorCompID, &Armor{5})
+	w.SetComponents(e, Mask{})
+	assert.True(t, w.Component(e, PositionCompID) == nil, "SetComponents should remove the components in the mask range")
+	assert.Equal(t, Armor{5}, *(*Armor)(w.Component(e, ArmorCompID)), "SetComponents should keep the components beyond MaskTotalBits")
+	w.RemComponents(e, ArmorCompID)
+	assert.True(t, w.Component(e, ArmorCompID) == nil, "RemComponents should remove components beyond MaskTotalBits")
+}
+
+func TestWorldReserveEntity(t *testing.T) {
+	const PositionCompID ComponentID = 0
+	type Position struct{ x, y float32 }
+
+	w := NewWorld(0)
+	w.Register(NewComponentRegistry[Position](PositionCompID))
+
+	reserved := make(chan EntityID, 100)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 25; j++ {
+				reserved <- w.ReserveEntity()
+			}
+		}()
+	}
+	wg.Wait()
+	close(reserved)
+
+	w.FlushReserved()
+	count := 0
+	for e := range reserved {
+		count++
+		assert.True(t, w.IsAlive(e), "FlushReserved should make the reserved entities alive")
+		w.AddComponent(e, PositionCompID)
+		assert.NotNil(t, w.Component(e, PositionCompID), "reserved entities should be usable after FlushReserved")
+	}
+	assert.Equal(t, 100, count, "expected all the reserved entities")
+	assert.Equal(t, uint(100), w.Stats().Entities, "FlushReserved should add the entities to the archetype graph")
+}
+
+func TestWorldInsertEntity(t *testing.T) {
+	const PositionCompID ComponentID = 0
+	type Position struct{ x, y float32 }
+
+	server := NewWorldWithEntityPool(NewEntityPoolRange(0, EntityRangeLow))
+	client := NewWorldWithEntityPool(NewPartitionedEntityPool(0, 1, EntityRangeLow, EntityRangeHigh))
+	for _, w := range []World{server, client} {
+		w.Register(NewComponentRegistry[Position](PositionCompID))
+	}
+
+	predicted := client.NewEntity(PositionCompID)
+	spawned := server.NewEntity(PositionCompID)
+	assert.NotEqual(t, predicted, spawned, "client and server IDs should not collide")
+
+	assert.True(t, client.InsertEntity(spawned, PositionCompID), "InsertEntity should accept the server IDs")
+	assert.True(t, client.SetComponent(spawned, PositionCompID, &Position{1, 2}), "InsertEntity should add the components")
+	assert.False(t, client.InsertEntity(spawned), "InsertEntity should return false for entities alive")
+	assert.False(t, client.InsertEntity(0), "InsertEntity should return false for invalid IDs")
+
+	client.RemEntity(spawned)
+	assert.False(t, client.IsAlive(spawned), "inserted entities should be removed as usual")
+}
+
+func TestUnconstrainedWorld(t *testing.T) {
+	const (
+		PositionCompID ComponentID = 10
+		HealthCompID   ComponentID = 300
+		EnemyCompID    ComponentID = 1000
+	)
+	type Position struct{ x, y float32 }
+	type Health struct{ value float32 }
+	type Enemy struct{}
+
+	assert.Panics(t, func() {
+		NewWorld(0).Register(NewComponentRegistry[Health](HealthCompID))
+	}, "constrained worlds should panic for components beyond MaxComponentCount")
+
+	w := NewUnconstrainedWorld(0)
+	w.Register(NewComponentRegistry[Position](PositionCompID))
+	w.Register(NewComponentRegistry[Health](HealthCompID))
+	w.Register(NewComponentRegistry[Enemy](EnemyCompID))
+
+	e1 := w.NewEntity(PositionCompID, HealthCompID)
+	e2 := w.NewEntity(HealthCompID, EnemyCompID)
+	e3 := w.NewEntity(PositionCompID)
+
+	assert.True(t, w.SetComponent(e1, HealthCompID, &Health{10}), "SetComponent should work for components beyond MaskTotalBits")
+	assert.True(t, w.SetComponent(e2, HealthCompID, &Health{20}), "SetComponent should work for components beyond MaskTotalBits")
+	assert.True(t, w.Component(e3, HealthCompID) == unsafe.Pointer(nil), "Component should return nil for missing components")
+
+	w.AddComponent(e1, EnemyCompID)
+	w.RemComponent(e2, EnemyCompID)
+	w.AddComponent(e3, PositionCompID)
+	w.RemComponent(e3, EnemyCompID)
+	assert.Equal(t, Health{10}, *(*Health)(w.Component(e1, HealthCompID)), "values should survive archetype moves")
+	assert.Equal(t, Health{20}, *(*Health)(w.Component(e2, HealthCompID)), "values should survive archetype moves")
+
+	count := func(query QueryCursor) int {
+		total := 0
+		for query.Next() {
+			total++
+		}
+		return total
+	}
+
+	assert.Equal(t, 1, count(w.QueryDynamic(MakeDynamicComponentMask(HealthCompID, EnemyCompID))), "QueryDynamic should find components beyond MaskTotalBits")
+	assert.Equal(t, 2, count(w.QueryDynamic(MakeDynamicComponentMask(HealthCompID))), "QueryDynamic should find components beyond MaskTotalBits")
+	assert.Equal(t, 2, count(w.QueryDynamic(MakeDynamicComponentMask(PositionCompID))), "QueryDynamic should find components lower than MaskTotalBits")
+	assert.Equal(t, 2, count(w.Query(MakeComponentMask(PositionCompID))), "Query should find components lower than MaskTotalBits")
+	assert.Equal(t, 3, count(w.QueryDynamic(DynamicMask{})), "QueryDynamic with empty mask should return all entities")
+
+	w.RemComponent(e1, EnemyCompID)
+	e4 := w.NewEntity(PositionCompID, HealthCompID)
+	arch1, _ := w.(*world).archGraph.Get(e1)
+	arch4, _ := w.(*world).archGraph.Get(e4)
+	assert.True(t, arch1 == arch4, "archetypes should not be duplicated")
+
+	constrained := NewWorld(0)
+	constrained.Register(NewComponentRegistry[Position](PositionCompID))
+	constrained.NewEntity(PositionCompID)
+	assert.Equal(t, 0, count(constrained.QueryDynamic(MakeDynamicComponentMask(PositionCompID, HealthCompID))), "constrained worlds should not find components beyond MaskTotalBits")
+	assert.Equal(t, 1, count(constrained.QueryDynamic(MakeDynamicComponentMask(PositionCompID))), "constrained worlds should find components with QueryDynamic")
+}